@@ -61,3 +61,43 @@ func TestAssertFailure(t *testing.T) {
 	assert.EQ(&tt, 1, 2, "test message %d", 10)
 	assert.Regexp(t, tt.msg, `Actual:.*1\nExpected:.*2\n.*test message 10$`)
 }
+
+// fatalTester records the message passed to Fatal, and counts the calls to
+// Helper.
+type fatalTester struct {
+	msg     string
+	helpers int
+}
+
+func (t *fatalTester) Fatal(args ...interface{}) { t.msg = args[0].(string) }
+func (t *fatalTester) Helper()                   { t.helpers++ }
+
+func TestAssertHelperAndCallerSkip(t *testing.T) {
+	tt := fatalTester{}
+	assert.NEQ(&tt, 1, 1)
+	assert.EQ(t, tt.helpers, 2)
+
+	tt = fatalTester{}
+	wrapper := func(t assert.TB) {
+		assert.EQ(assert.WithCallerSkip(t, 1), "got", "want")
+	}
+	wrapper(&tt) // The failure is reported here.
+	assert.HasSubstr(t, tt.msg, ": wrapper(&tt) // The failure is reported here.\n")
+	// EQ, That, and the Fatal method of the wrapped TB.
+	assert.EQ(t, tt.helpers, 3)
+}
+
+func TestWithCallerSkipMethods(t *testing.T) {
+	// The result supports the optional methods of the wrapped TB.
+	st := assert.WithCallerSkip(t, 1)
+	_, ok := st.(interface{ Cleanup(func()) })
+	assert.True(t, ok)
+	_, ok = st.(interface {
+		Failed() bool
+		FailNow()
+	})
+	assert.True(t, ok)
+
+	_, ok = assert.WithCallerSkip(&fatalTester{}, 1).(interface{ Cleanup(func()) })
+	assert.False(t, ok)
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Options configures Generate.
+type Options struct {
+	// Interfaces lists the interfaces to mock. If empty, all the interfaces
+	// declared in the source file are mocked.
+	Interfaces []string
+	// Package is the package of the generated code. If empty, it is the
+	// package of the source file.
+	Package string
+	// SelfImport is the import path of the source package. It is needed if
+	// Package is another package, to qualify the types of the source package.
+	SelfImport string
+}
+
+// method is a method of a mocked interface.
+type method struct {
+	name     string
+	params   []param
+	results  []ast.Expr
+	variadic bool // the last param is variadic.
+}
+
+type param struct {
+	name string
+	typ  ast.Expr // for a variadic param, the type of the slice, e.g., []string.
+}
+
+// generator holds the state of one Generate call.
+type generator struct {
+	fset    *token.FileSet
+	file    *ast.File
+	opts    Options
+	imports map[string]string // package name -> import path, of the source file.
+	used    map[string]bool   // package names used by the generated code.
+	qualify string            // if not empty, the name that qualifies the types of the source package.
+}
+
+// Generate generates the mocks for the interfaces declared in src. filename is
+// used in error messages.
+func Generate(filename string, src []byte, opts Options) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{fset: fset, file: file, opts: opts, imports: map[string]string{}, used: map[string]bool{}}
+	if opts.Package == "" {
+		g.opts.Package = file.Name.Name
+	}
+	if g.opts.Package != file.Name.Name {
+		if opts.SelfImport == "" {
+			return nil, fmt.Errorf("%s: -self_import is needed to generate package %s", filename, g.opts.Package)
+		}
+		g.qualify = file.Name.Name
+		g.imports[g.qualify] = opts.SelfImport
+	}
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := importName(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		g.imports[name] = p
+	}
+	decls := g.interfaces()
+	names := opts.Interfaces
+	if len(names) == 0 {
+		for name := range decls {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s: no interfaces found", filename)
+	}
+	body := bytes.NewBuffer(nil)
+	for _, name := range names {
+		it, ok := decls[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: interface %s not found", filename, name)
+		}
+		if it.TypeParams != nil {
+			return nil, fmt.Errorf("%s: generic interface %s is not supported", filename, name)
+		}
+		methods, err := g.methods(decls, name, it.Type.(*ast.InterfaceType), map[string]bool{})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		g.writeMock(body, name, methods)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("// Code generated by hmockgen. DO NOT EDIT.\n\n")
+	buf.WriteString("package " + g.opts.Package + "\n\n")
+	buf.WriteString("import (\n")
+	var used []string
+	for name := range g.used {
+		used = append(used, name)
+	}
+	sort.Slice(used, func(i, j int) bool { return g.imports[used[i]] < g.imports[used[j]] })
+	for _, name := range used {
+		p := g.imports[name]
+		if name == importName(p) {
+			buf.WriteString(fmt.Sprintf("\t%q\n", p))
+		} else {
+			buf.WriteString(fmt.Sprintf("\t%s %q\n", name, p))
+		}
+	}
+	if len(used) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\t\"github.com/grailbio/testutil/mock\"\n)\n")
+	buf.Write(body.Bytes())
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v\n%s", err, buf.Bytes())
+	}
+	return code, nil
+}
+
+// importName guesses the package name of an import path, e.g., "yaml" for
+// "gopkg.in/yaml.v2".
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	if v := strings.TrimLeft(name, "v0123456789"); v == "" && strings.HasPrefix(name, "v") {
+		// A major version suffix, e.g., "github.com/foo/bar/v2".
+		name = path.Base(path.Dir(importPath))
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+// interfaces returns the interfaces declared in the source file.
+func (g *generator) interfaces() map[string]*ast.TypeSpec {
+	decls := map[string]*ast.TypeSpec{}
+	for _, decl := range g.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if _, ok := ts.Type.(*ast.InterfaceType); ok {
+				decls[ts.Name.Name] = ts
+			}
+		}
+	}
+	return decls
+}
+
+// methods lists the methods of an interface, including the ones of the
+// embedded interfaces, sorted by name. visiting detects embedding cycles.
+func (g *generator) methods(decls map[string]*ast.TypeSpec, name string, it *ast.InterfaceType, visiting map[string]bool) ([]method, error) {
+	if visiting[name] {
+		return nil, fmt.Errorf("interface %s embeds itself", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+	byName := map[string]method{}
+	for _, field := range it.Methods.List {
+		switch t := field.Type.(type) {
+		case *ast.FuncType:
+			for _, n := range field.Names {
+				byName[n.Name] = g.method(n.Name, t)
+			}
+		case *ast.Ident, *ast.SelectorExpr:
+			embeddedName := g.text(t)
+			ts, ok := decls[embeddedName]
+			if embeddedName == "error" {
+				ts, ok = errorInterface, true
+			}
+			if !ok {
+				ts, ok = wellKnownInterfaces[embeddedName]
+			}
+			if !ok {
+				return nil, fmt.Errorf("interface %s: embedded interface %s must be declared in the same file", name, embeddedName)
+			}
+			embedded, err := g.methods(decls, embeddedName, ts.Type.(*ast.InterfaceType), visiting)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range embedded {
+				byName[m.name] = m
+			}
+		default:
+			return nil, fmt.Errorf("interface %s: unsupported embedded type %s", name, g.text(field.Type))
+		}
+	}
+	var methods []method
+	for _, m := range byName {
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+	return methods, nil
+}
+
+// wellKnownInterfaces are the interfaces of other packages that can be
+// embedded in a mocked interface. Their methods only use predeclared types.
+var wellKnownInterfaces = func() map[string]*ast.TypeSpec {
+	const src = `package p
+type fmt_Stringer interface { String() string }
+type io_Reader interface { Read(p []byte) (n int, err error) }
+type io_Writer interface { Write(p []byte) (n int, err error) }
+type io_Closer interface { Close() error }
+type io_ReadWriter interface { io_Reader; io_Writer }
+type io_ReadCloser interface { io_Reader; io_Closer }
+type io_WriteCloser interface { io_Writer; io_Closer }
+type io_ReadWriteCloser interface { io_Reader; io_Writer; io_Closer }
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		panic(err)
+	}
+	// The interfaces are declared as, e.g., io_Reader, since io.Reader is not
+	// an identifier. They are registered under both spellings, so that the
+	// embeddings within src are found too.
+	decls := map[string]*ast.TypeSpec{}
+	for _, decl := range file.Decls {
+		ts := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+		decls[ts.Name.Name] = ts
+		decls[strings.Replace(ts.Name.Name, "_", ".", 1)] = ts
+	}
+	return decls
+}()
+
+// errorInterface is the predeclared interface error.
+var errorInterface = func() *ast.TypeSpec {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p; type error interface { Error() string }", 0)
+	if err != nil {
+		panic(err)
+	}
+	return file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+}()
+
+// reservedNames are the identifiers used by the generated methods, which
+// parameters must not shadow.
+var reservedNames = map[string]bool{"m": true, "r": true, "ret": true, "mock": true}
+
+func (g *generator) method(name string, ft *ast.FuncType) method {
+	m := method{name: name}
+	if ft.Params != nil {
+		for _, field := range ft.Params.List {
+			typ := field.Type
+			if e, ok := typ.(*ast.Ellipsis); ok {
+				m.variadic = true
+				typ = &ast.ArrayType{Elt: e.Elt}
+			}
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent("_")}
+			}
+			for _, n := range names {
+				pname := n.Name
+				if pname == "_" {
+					pname = fmt.Sprintf("arg%d", len(m.params))
+				} else if reservedNames[pname] {
+					pname += "_"
+				}
+				m.params = append(m.params, param{pname, typ})
+			}
+		}
+	}
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				m.results = append(m.results, field.Type)
+			}
+		}
+	}
+	return m
+}
+
+// typeString renders a type of the source file for the generated code. It
+// records the imports used by the type, and qualifies the types of the source
+// package if the generated code is in another package.
+func (g *generator) typeString(typ ast.Expr) string {
+	typ = g.rewrite(typ)
+	return g.text(typ)
+}
+
+// rewrite returns a copy of typ with the types of the source package
+// qualified, if needed.
+func (g *generator) rewrite(typ ast.Expr) ast.Expr {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		if g.qualify != "" && isExported(t.Name) {
+			g.used[g.qualify] = true
+			return &ast.SelectorExpr{X: ast.NewIdent(g.qualify), Sel: ast.NewIdent(t.Name)}
+		}
+		return t
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			g.used[x.Name] = true
+		}
+		return t
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: g.rewrite(t.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: g.rewrite(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: g.rewrite(t.Key), Value: g.rewrite(t.Value)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: t.Dir, Value: g.rewrite(t.Value)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: g.rewrite(t.Elt)}
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{X: g.rewrite(t.X)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: g.rewrite(t.X), Index: g.rewrite(t.Index)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(t.Indices))
+		for i, index := range t.Indices {
+			indices[i] = g.rewrite(index)
+		}
+		return &ast.IndexListExpr{X: g.rewrite(t.X), Indices: indices}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: g.rewriteFields(t.Params), Results: g.rewriteFields(t.Results)}
+	case *ast.StructType:
+		return &ast.StructType{Fields: g.rewriteFields(t.Fields)}
+	case *ast.InterfaceType:
+		return &ast.InterfaceType{Methods: g.rewriteFields(t.Methods)}
+	}
+	return typ
+}
+
+func (g *generator) rewriteFields(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	result := &ast.FieldList{}
+	for _, f := range fields.List {
+		result.List = append(result.List, &ast.Field{Names: f.Names, Type: g.rewrite(f.Type), Tag: f.Tag})
+	}
+	return result
+}
+
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// text returns the source text of a node.
+func (g *generator) text(n ast.Node) string {
+	buf := bytes.NewBuffer(nil)
+	if err := printer.Fprint(buf, g.fset, n); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// writeMock generates the mock of one interface.
+func (g *generator) writeMock(w *bytes.Buffer, name string, methods []method) {
+	mockName := "Mock" + name
+	recName := mockName + "Recorder"
+	fmt.Fprintf(w, `
+// %[1]s is a mock of the %[2]s interface.
+type %[1]s struct {
+	ctrl *mock.Controller
+}
+
+// New%[1]s creates a mock of %[2]s that records the calls in ctrl.
+func New%[1]s(ctrl *mock.Controller) *%[1]s {
+	return &%[1]s{ctrl: ctrl}
+}
+
+// %[3]s registers the expected calls of a %[1]s.
+type %[3]s struct {
+	mock *%[1]s
+}
+
+// EXPECT returns an object that registers the expected calls.
+func (m *%[1]s) EXPECT() *%[3]s {
+	return &%[3]s{mock: m}
+}
+`, mockName, name, recName)
+	for _, m := range methods {
+		var params, args, recParams []string
+		for i, p := range m.params {
+			typ := g.typeString(p.typ)
+			if m.variadic && i == len(m.params)-1 {
+				typ = "..." + strings.TrimPrefix(typ, "[]")
+			}
+			params = append(params, p.name+" "+typ)
+			args = append(args, p.name)
+			recParams = append(recParams, p.name)
+		}
+		var results []string
+		for _, r := range m.results {
+			results = append(results, g.typeString(r))
+		}
+		resultStr := strings.Join(results, ", ")
+		if len(results) > 1 {
+			resultStr = "(" + resultStr + ")"
+		}
+		callArgs := ""
+		if len(args) > 0 {
+			callArgs = ", " + strings.Join(args, ", ")
+		}
+		fmt.Fprintf(w, "\n// %s implements %s.%s.\n", m.name, name, m.name)
+		fmt.Fprintf(w, "func (m *%s) %s(%s) %s {\n", mockName, m.name, strings.Join(params, ", "), resultStr)
+		if len(results) == 0 {
+			fmt.Fprintf(w, "\tm.ctrl.Call(m, %q%s)\n", m.name, callArgs)
+		} else {
+			fmt.Fprintf(w, "\tret := m.ctrl.Call(m, %q%s)\n", m.name, callArgs)
+			var rets []string
+			for i, r := range results {
+				fmt.Fprintf(w, "\tret%d, _ := ret[%d].(%s)\n", i, i, r)
+				rets = append(rets, fmt.Sprintf("ret%d", i))
+			}
+			fmt.Fprintf(w, "\treturn %s\n", strings.Join(rets, ", "))
+		}
+		fmt.Fprintf(w, "}\n")
+
+		recSig := ""
+		if len(recParams) > 0 {
+			recSig = strings.Join(recParams, ", ") + " interface{}"
+		}
+		fmt.Fprintf(w, "\n// %s registers an expected call of %s.", m.name, m.name)
+		if len(recParams) > 0 {
+			fmt.Fprintf(w, " The arguments are values or h matchers.")
+		}
+		fmt.Fprintf(w, "\n")
+		fmt.Fprintf(w, "func (r *%s) %s(%s) *mock.Call {\n", recName, m.name, recSig)
+		fmt.Fprintf(w, "\treturn r.mock.ctrl.Expect(r.mock, %q%s)\n", m.name, callArgs)
+		fmt.Fprintf(w, "}\n")
+	}
+}
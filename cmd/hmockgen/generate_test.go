@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/grailbio/testutil/assert"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestGenerateMatchesCheckedInMock(t *testing.T) {
+	src, err := ioutil.ReadFile("../../mock/store_test.go")
+	assert.NoError(t, err)
+	want, err := ioutil.ReadFile("../../mock/mock_store_test.go")
+	assert.NoError(t, err)
+	got, err := Generate("store_test.go", src, Options{Interfaces: []string{"Store"}})
+	assert.NoError(t, err)
+	expect.That(t, string(got), h.StringEQ(string(want)), "run go generate in mock/")
+}
+
+const kvSource = `package kv
+
+import (
+	ctx "context"
+	"gopkg.in/yaml.v2"
+)
+
+type Key string
+
+type Getter interface {
+	Get(c ctx.Context, k Key) (*Value, error)
+}
+
+type KV interface {
+	Getter
+	error
+	Set(_ Key, m map[Key][]*Value, fn func(Key) bool, ch <-chan yaml.MapItem)
+}
+
+type Value struct{}
+`
+
+func TestGenerateOtherPackage(t *testing.T) {
+	got, err := Generate("kv.go", []byte(kvSource), Options{
+		Interfaces: []string{"KV"},
+		Package:    "kv_test",
+		SelfImport: "example.com/kv",
+	})
+	assert.NoError(t, err)
+	expect.That(t, string(got), h.AllOf(
+		h.HasPrefix("// Code generated by hmockgen. DO NOT EDIT.\n\npackage kv_test\n"),
+		h.HasSubstr("ctx \"context\"\n\t\"example.com/kv\"\n\t\"gopkg.in/yaml.v2\"\n\n\t\"github.com/grailbio/testutil/mock\"\n"),
+		h.HasSubstr("func (m *MockKV) Get(c ctx.Context, k kv.Key) (*kv.Value, error) {"),
+		h.HasSubstr("func (m *MockKV) Error() string {"),
+		h.HasSubstr("func (m *MockKV) Set(arg0 kv.Key, m_ map[kv.Key][]*kv.Value, fn func(kv.Key) bool, ch <-chan yaml.MapItem) {\n\tm.ctrl.Call(m, \"Set\", arg0, m_, fn, ch)\n}"),
+		h.HasSubstr("func (r *MockKVRecorder) Set(arg0, m_, fn, ch interface{}) *mock.Call {")))
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := Generate("kv.go", []byte(kvSource), Options{Interfaces: []string{"Missing"}})
+	expect.That(t, err, h.ErrorMessage(h.HasSubstr("interface Missing not found")))
+	_, err = Generate("kv.go", []byte(kvSource), Options{Package: "other"})
+	expect.That(t, err, h.ErrorMessage(h.HasSubstr("-self_import is needed")))
+	_, err = Generate("x.go", []byte("package x\ntype I interface{ sort.Interface }\n"), Options{})
+	expect.That(t, err, h.ErrorMessage(h.HasSubstr("embedded interface sort.Interface must be declared in the same file")))
+	_, err = Generate("x.go", []byte("package x\ntype I[T any] interface{ Get() T }\n"), Options{})
+	expect.That(t, err, h.ErrorMessage(h.HasSubstr("generic interface I is not supported")))
+	_, err = Generate("x.go", []byte("package x\n"), Options{})
+	expect.That(t, err, h.ErrorMessage(h.HasSubstr("no interfaces found")))
+}
+
+func TestImportName(t *testing.T) {
+	expect.EQ(t, importName("context"), "context")
+	expect.EQ(t, importName("gopkg.in/yaml.v2"), "yaml")
+	expect.EQ(t, importName("github.com/foo/bar/v2"), "bar")
+	expect.EQ(t, importName("github.com/mattn/go-isatty"), "isatty")
+}
@@ -0,0 +1,70 @@
+// Command hmockgen generates mocks for Go interfaces. The expected calls of the
+// mocks are specified with the matchers of package
+// github.com/grailbio/testutil/h, and checked by package
+// github.com/grailbio/testutil/mock.
+//
+// Usage:
+//   hmockgen -source store.go [-interfaces Store,Cache] [-package foo_test]
+//       [-self_import github.com/me/foo] [-out mock_store_test.go]
+//
+// For every interface Foo, hmockgen generates a type MockFoo that implements
+// Foo, a constructor NewMockFoo(*mock.Controller), and a method EXPECT that
+// registers the expected calls:
+//
+//   ctrl := mock.NewController(t)
+//   store := NewMockStore(ctrl)
+//   store.EXPECT().Get(h.HasPrefix("user/")).Return([]byte("alice"), nil)
+//
+// The variadic arguments of a method are matched as a single slice. Embedded
+// interfaces must be declared in the same source file, or be "error".
+//
+// It is typically invoked by a go:generate directive:
+//
+//   //go:generate go run github.com/grailbio/testutil/cmd/hmockgen -source store.go -out mock_store_test.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		source     = flag.String("source", "", "Go source file that declares the interfaces")
+		interfaces = flag.String("interfaces", "", "comma-separated names of the interfaces to mock; all the interfaces of the source file by default")
+		pkg        = flag.String("package", "", "package of the generated code; the package of the source file by default")
+		selfImport = flag.String("self_import", "", "import path of the source package, needed if -package is another package")
+		out        = flag.String("out", "", "output file; the standard output by default")
+	)
+	log.SetFlags(0)
+	log.SetPrefix("hmockgen: ")
+	flag.Parse()
+	if *source == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	src, err := ioutil.ReadFile(*source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := Options{Package: *pkg, SelfImport: *selfImport}
+	if *interfaces != "" {
+		opts.Interfaces = strings.Split(*interfaces, ",")
+	}
+	code, err := Generate(*source, src, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = ioutil.WriteFile(*out, code, 0644)
+	}
+	if err != nil {
+		log.Fatal(fmt.Errorf("write: %v", err))
+	}
+}
@@ -0,0 +1,55 @@
+package expect
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/grailbio/testutil/h"
+)
+
+// CleanupTB is a TB that supports Cleanup, such as *testing.T and *testing.B.
+type CleanupTB interface {
+	TB
+	Cleanup(func())
+}
+
+// Before registers an expectation that must hold by the end of the test. check
+// is called when the test finishes, in t.Cleanup, and a failed result is
+// reported like a failure of That. If msgs... is not empty, msgs[0] must be a
+// format string, and they are printed using fmt.Printf on error.
+//
+// Combined with call counters, it gives gmock-style call expectations:
+//
+//   client := s3test.NewClient(t, "bucket")
+//   expect.Before(t, func() h.Result {
+//     return h.GE(1).Match(client.GetApiCount("GetObjectRequest"))
+//   }, "expected at least one GetObject call")
+func Before(t CleanupTB, check func() h.Result, msgs ...interface{}) {
+	registerBefore(t, check, msgs)
+}
+
+// BeforeThat is a shorthand for Before(t, func() h.Result { return
+// m.Match(get()) }, msgs...). get is called when the test finishes.
+//
+// Example:
+//   var calls int32
+//   expect.BeforeThat(t, func() interface{} { return atomic.LoadInt32(&calls) }, h.EQ(int32(2)))
+func BeforeThat(t CleanupTB, get func() interface{}, m *h.Matcher, msgs ...interface{}) {
+	registerBefore(t, func() h.Result { return m.Match(get()) }, msgs)
+}
+
+// registerBefore implements Before and BeforeThat. The failure message names
+// the caller of its caller, which registered the expectation.
+func registerBefore(t CleanupTB, check func() h.Result, msgs []interface{}) {
+	heading := "Unmet expectation:\n"
+	if _, file, line, ok := runtime.Caller(2); ok {
+		heading = fmt.Sprintf("Unmet expectation registered at %s:%d:\n", file, line)
+	}
+	t.Cleanup(func() {
+		r := check()
+		if r.Status() == h.Match {
+			return
+		}
+		fail(t, r, heading, msgs)
+	})
+}
@@ -0,0 +1,51 @@
+package expect_test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+// cleanupTester runs the cleanup functions on demand.
+type cleanupTester struct {
+	tester
+	cleanups []func()
+}
+
+func (t *cleanupTester) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *cleanupTester) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestBefore(t *testing.T) {
+	tt := &cleanupTester{}
+	var calls int32
+	expect.Before(tt, func() h.Result { return h.EQ(int32(2)).Match(atomic.LoadInt32(&calls)) }, "callback count")
+	atomic.AddInt32(&calls, 1)
+	atomic.AddInt32(&calls, 1)
+	tt.finish()
+	expect.EQ(t, tt.msg, "")
+
+	tt = &cleanupTester{}
+	calls = 0
+	expect.BeforeThat(tt, func() interface{} { return atomic.LoadInt32(&calls) }, h.GE(int32(1)), "at least %d call", 1)
+	expect.EQ(t, tt.msg, "") // Not checked until the test finishes.
+	tt.finish()
+	expect.That(t, tt.msg, h.AllOf(
+		h.Regexp(`^Unmet expectation registered at \S+before_test.go:\d+:\n`),
+		h.HasSubstr("Actual:   (int32)0\nExpected: >= (int32)1\n"),
+		h.HasSuffix("at least 1 call")))
+}
+
+func TestBeforeWithTesting(t *testing.T) {
+	var done bool
+	t.Run("sub", func(t *testing.T) {
+		expect.BeforeThat(t, func() interface{} { return done }, h.EQ(true))
+		done = true
+	})
+}
@@ -2,6 +2,7 @@ package expect
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/grailbio/testutil/h"
 )
@@ -11,15 +12,128 @@ type TB interface {
 	Error(sargs ...interface{})
 }
 
+// helper is implemented by *testing.T and *testing.B. The functions of this
+// package call Helper, if the TB supports it, so that the failures are
+// reported at the line of the test, rather than inside this package.
+type helper interface {
+	Helper()
+}
+
+// callerSkipper is implemented by the TB returned by WithCallerSkip.
+type callerSkipper interface {
+	CallerSkip() int
+}
+
+// skipTB is the TB returned by WithCallerSkip. cleanupSkipTB and
+// testingSkipTB extend it with the optional methods of the wrapped TB.
+type skipTB struct {
+	TB
+	skip int
+}
+
+// cleanupSkipTB is returned by WithCallerSkip for a TB that supports Cleanup.
+type cleanupSkipTB struct{ skipTB }
+
+// testingSkipTB is returned by WithCallerSkip for a TB that supports Cleanup,
+// Failed and FailNow, such as *testing.T.
+type testingSkipTB struct{ cleanupSkipTB }
+
+// failer is implemented by *testing.T and *testing.B.
+type failer interface {
+	Failed() bool
+	FailNow()
+}
+
+func (t skipTB) CallerSkip() int { return t.skip }
+
+func (t skipTB) unwrapSkip() skipTB { return t }
+
+func (t cleanupSkipTB) Cleanup(fn func()) {
+	t.TB.(interface{ Cleanup(func()) }).Cleanup(fn)
+}
+
+func (t testingSkipTB) Failed() bool { return t.TB.(failer).Failed() }
+
+func (t testingSkipTB) FailNow() {
+	if th, ok := t.TB.(helper); ok {
+		th.Helper()
+	}
+	t.TB.(failer).FailNow()
+}
+
+func (t skipTB) Error(args ...interface{}) {
+	if th, ok := t.TB.(helper); ok {
+		th.Helper()
+	}
+	t.TB.Error(args...)
+}
+
+func (t skipTB) Helper() {
+	if th, ok := t.TB.(helper); ok {
+		th.Helper()
+	}
+}
+
+func (t skipTB) Name() string {
+	if tn, ok := t.TB.(interface{ Name() string }); ok {
+		return tn.Name()
+	}
+	return ""
+}
+
+// WithCallerSkip wraps t, so that the failures reported through it point skip
+// frames further up the stack. It is meant for helper libraries that wrap the
+// functions of this package: a helper passes WithCallerSkip(t, 1) to report
+// failures at the line that called the helper. The result also supports
+// Cleanup, Failed and FailNow if t does, as *testing.T does, so that it can be
+// passed to Group or to mock.NewController.
+//
+// Example:
+//   func checkUser(t *testing.T, u *User) {
+//     t.Helper()
+//     expect.That(expect.WithCallerSkip(t, 1), u.Name, h.Not(h.EQ("")))
+//   }
+func WithCallerSkip(t TB, skip int) TB {
+	if skip < 0 {
+		panic("expect.WithCallerSkip: skip < 0")
+	}
+	if st, ok := t.(interface{ unwrapSkip() skipTB }); ok {
+		inner := st.unwrapSkip()
+		t, skip = inner.TB, inner.skip+skip
+	}
+	st := skipTB{t, skip}
+	if _, ok := t.(interface{ Cleanup(func()) }); !ok {
+		return st
+	}
+	if _, ok := t.(failer); !ok {
+		return cleanupSkipTB{st}
+	}
+	return testingSkipTB{cleanupSkipTB{st}}
+}
+
 // That checks if the gives value matches the matcher. If msgs... is not empty,
 // msgs[0] must be a format string, and they are printed using fmt.Printf on
 // error.
 func That(t TB, val interface{}, m *h.Matcher, msgs ...interface{}) {
-
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	r := m.Match(val)
 	if r.Status() == h.Match {
 		return
 	}
+	fail(t, r, "", msgs)
+}
+
+// fail reports a failed result to t. heading, if not empty, is printed before
+// the result.
+func fail(t TB, r h.Result, heading string, msgs []interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	if ts, ok := t.(callerSkipper); ok {
+		r = r.SkipCallers(ts.CallerSkip())
+	}
 	var msg string
 	switch len(msgs) {
 	case 0:
@@ -29,5 +143,6 @@ func That(t TB, val interface{}, m *h.Matcher, msgs ...interface{}) {
 		f := msgs[0].(string)
 		msg = " " + fmt.Sprintf(f, msgs[1:]...)
 	}
-	t.Error(r.String() + " " + msg)
+	h.ReportFailure(t, r, strings.TrimSpace(msg))
+	t.Error(heading + r.String() + " " + msg)
 }
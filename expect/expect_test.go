@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
 )
 
 type tester struct {
@@ -26,3 +27,64 @@ func TestExpectFailure(t *testing.T) {
 	expect.EQ(&tt, 1, 2, "test message %d", 10)
 	expect.Regexp(t, tt.msg, `Actual:.*1\nExpected:.*2\n.*test message 10$`)
 }
+
+// helperTester counts the calls to Helper.
+type helperTester struct {
+	tester
+	helpers int
+}
+
+func (t *helperTester) Helper() { t.helpers++ }
+
+func TestExpectCallsHelper(t *testing.T) {
+	tt := helperTester{}
+	expect.EQ(&tt, 1, 2)
+	// EQ, That, and the function that reports the failure.
+	expect.EQ(t, tt.helpers, 3)
+
+	tt = helperTester{}
+	expect.True(&tt, false)
+	// True, EQ, That, and the function that reports the failure.
+	expect.EQ(t, tt.helpers, 4)
+}
+
+// checkPositive is a helper of a hypothetical wrapper library.
+func checkPositive(t expect.TB, v int) {
+	expect.GT(expect.WithCallerSkip(t, 1), v, 0)
+}
+
+func TestExpectWithCallerSkip(t *testing.T) {
+	tt := tester{}
+	n := -1
+	checkPositive(&tt, n) // The failure is reported here.
+	expect.HasPrefix(t, tt.msg, "/")
+	expect.HasSubstr(t, tt.msg, "expect_test.go")
+	expect.HasSubstr(t, tt.msg, ": checkPositive(&tt, n) // The failure is reported here.\n")
+	expect.That(t, tt.msg, h.Not(h.HasSubstr("expect.GT(")))
+
+	tt = tester{}
+	checkPositive(&tt, 1)
+	expect.EQ(t, tt.msg, "")
+}
+
+func TestWithCallerSkipMethods(t *testing.T) {
+	// The result supports the optional methods of the wrapped TB.
+	st := expect.WithCallerSkip(t, 1)
+	_, ok := st.(expect.CleanupTB)
+	expect.True(t, ok)
+	_, ok = st.(interface {
+		Failed() bool
+		FailNow()
+	})
+	expect.True(t, ok)
+	expect.False(t, st.(interface{ Failed() bool }).Failed())
+
+	st = expect.WithCallerSkip(&cleanupTester{}, 1)
+	_, ok = expect.WithCallerSkip(st, 1).(expect.CleanupTB)
+	expect.True(t, ok)
+	_, ok = st.(interface{ FailNow() })
+	expect.False(t, ok)
+
+	_, ok = expect.WithCallerSkip(&tester{}, 1).(expect.CleanupTB)
+	expect.False(t, ok)
+}
@@ -0,0 +1,134 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grailbio/testutil/h"
+)
+
+// GroupTB collects the failures of a group of related checks, and reports
+// them to the underlying test as one combined failure. It implements TB, so it
+// can be passed to That, EQ and the other functions of this package in place
+// of the test. Create one with Group.
+type GroupTB struct {
+	t       TB
+	heading string
+
+	mu       sync.Mutex
+	failures []string
+	done     bool
+}
+
+// Group starts a group of soft assertions. The failures of the checks made
+// with the group are not reported immediately, but collected until Done or
+// DoneFatal is called, and then reported by a single t.Error call under the
+// given heading. If t supports Cleanup, as *testing.T does, the group is
+// finished automatically at the end of the test if the test didn't call Done
+// or DoneFatal.
+//
+// Example:
+//   g := expect.Group(t, "response")
+//   expect.EQ(g, resp.Code, 200)
+//   expect.That(g, resp.Header.Get("Content-Type"), h.HasPrefix("text/html"))
+//   g.That(resp.Body, h.HasSubstr("<title>"))
+//   g.DoneFatal()
+func Group(t TB, heading string) *GroupTB {
+	g := &GroupTB{t: t, heading: heading}
+	if tc, ok := t.(interface{ Cleanup(func()) }); ok {
+		tc.Cleanup(g.Done)
+	}
+	return g
+}
+
+// Error records a failure. It is called by That, EQ and similar functions. A
+// failure recorded after Done is reported to the test immediately.
+func (g *GroupTB) Error(args ...interface{}) {
+	if th, ok := g.t.(helper); ok {
+		th.Helper()
+	}
+	msg := strings.TrimSpace(fmt.Sprint(args...))
+	g.mu.Lock()
+	done := g.done
+	if !done {
+		g.failures = append(g.failures, msg)
+	}
+	g.mu.Unlock()
+	if done {
+		g.t.Error(g.heading + ": " + msg)
+	}
+}
+
+// Helper calls Helper on the underlying test, if it supports it, as *testing.T
+// does.
+func (g *GroupTB) Helper() {
+	if th, ok := g.t.(helper); ok {
+		th.Helper()
+	}
+}
+
+// Name returns the name of the underlying test, if it has one.
+func (g *GroupTB) Name() string {
+	if tn, ok := g.t.(interface{ Name() string }); ok {
+		return tn.Name()
+	}
+	return ""
+}
+
+// That is a shorthand for expect.That(g, val, m, msgs...).
+func (g *GroupTB) That(val interface{}, m *h.Matcher, msgs ...interface{}) {
+	That(g, val, m, msgs...)
+}
+
+// Failed checks if any check of the group has failed so far.
+func (g *GroupTB) Failed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.failures) > 0
+}
+
+// Done reports the failures collected so far as one failure of the underlying
+// test, if there are any. Later calls to Done or DoneFatal do nothing.
+func (g *GroupTB) Done() {
+	if th, ok := g.t.(helper); ok {
+		th.Helper()
+	}
+	g.mu.Lock()
+	failures := g.failures
+	done := g.done
+	g.done = true
+	g.mu.Unlock()
+	if done || len(failures) == 0 {
+		return
+	}
+	buf := strings.Builder{}
+	noun := "checks"
+	if len(failures) == 1 {
+		noun = "check"
+	}
+	buf.WriteString(fmt.Sprintf("%s: %d %s failed", g.heading, len(failures), noun))
+	for i, f := range failures {
+		buf.WriteString(fmt.Sprintf("\n\n--- %s: failure %d of %d ---\n", g.heading, i+1, len(failures)))
+		buf.WriteString(f)
+	}
+	g.t.Error(buf.String())
+}
+
+// DoneFatal is like Done, but it also stops the test by calling t.FailNow if
+// any check has failed. t must support FailNow, as *testing.T does.
+func (g *GroupTB) DoneFatal() {
+	if th, ok := g.t.(helper); ok {
+		th.Helper()
+	}
+	failed := g.Failed()
+	g.Done()
+	if !failed {
+		return
+	}
+	tf, ok := g.t.(interface{ FailNow() })
+	if !ok {
+		panic(fmt.Sprintf("expect.Group: %T doesn't support FailNow", g.t))
+	}
+	tf.FailNow()
+}
@@ -0,0 +1,124 @@
+package expect_test
+
+import (
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+// groupTester records the calls made by a group.
+type groupTester struct {
+	errors  []string
+	failNow bool
+	helpers int
+}
+
+func (t *groupTester) Error(args ...interface{}) { t.errors = append(t.errors, args[0].(string)) }
+func (t *groupTester) FailNow()                  { t.failNow = true }
+func (t *groupTester) Helper()                   { t.helpers++ }
+
+func TestGroup(t *testing.T) {
+	tt := &groupTester{}
+	g := expect.Group(tt, "record")
+	expect.EQ(g, 1, 1)
+	expect.EQ(g, "bob", "alice", "name")
+	v := 42
+	g.That(v, h.LT(10))
+	expect.True(t, g.Failed())
+	expect.EQ(t, len(tt.errors), 0)
+
+	g.Done()
+	expect.EQ(t, len(tt.errors), 1)
+	expect.That(t, tt.errors[0], h.AllOf(
+		h.HasPrefix("record: 2 checks failed\n\n--- record: failure 1 of 2 ---\n"),
+		h.HasSubstr("g.That(v, h.LT(10))\n\tv = (int)42\n"),
+		h.Regexp(`(?s)Actual: +\(string\)bob\nExpected: +\(string\)alice\n +name\n\n--- record: failure 2 of 2 ---\n.*Expected: is < \(int\)10$`)))
+
+	// Done only reports once, and later failures are reported immediately.
+	g.Done()
+	expect.EQ(t, len(tt.errors), 1)
+	expect.EQ(g, 1, 2)
+	expect.EQ(t, len(tt.errors), 2)
+	expect.HasPrefix(t, tt.errors[1], "record: ")
+	expect.False(t, tt.failNow)
+}
+
+func TestGroupDoneFatal(t *testing.T) {
+	tt := &groupTester{}
+	g := expect.Group(tt, "ok")
+	expect.EQ(g, 1, 1)
+	g.DoneFatal()
+	expect.EQ(t, len(tt.errors), 0)
+	expect.False(t, tt.failNow)
+
+	g = expect.Group(tt, "bad")
+	expect.EQ(g, 1, 2)
+	g.DoneFatal()
+	expect.That(t, tt.errors, h.ElementsAre(h.HasPrefix("bad: 1 check failed")))
+	expect.True(t, tt.failNow)
+}
+
+func TestGroupCallsHelper(t *testing.T) {
+	tt := &groupTester{}
+	g := expect.Group(tt, "g")
+	expect.EQ(g, 1, 2)
+	// EQ, That and the function that reports the failure call g.Helper, and
+	// then g.Error reports the failure.
+	expect.EQ(t, tt.helpers, 4)
+	g.DoneFatal()
+	// DoneFatal and Done.
+	expect.EQ(t, tt.helpers, 6)
+}
+
+// groupCleanupTester is a groupTester that runs the cleanup functions on
+// demand.
+type groupCleanupTester struct {
+	groupTester
+	cleanups []func()
+}
+
+func (t *groupCleanupTester) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *groupCleanupTester) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestGroupCleanup(t *testing.T) {
+	var g *expect.GroupTB
+	t.Run("sub", func(t *testing.T) {
+		g = expect.Group(t, "cleanup")
+		expect.EQ(g, 1, 1)
+	})
+	expect.False(t, g.Failed())
+
+	// The failures that the test didn't report are reported at its end.
+	tt := &groupCleanupTester{}
+	g = expect.Group(tt, "pending")
+	expect.EQ(g, 1, 2)
+	expect.EQ(g, "a", "b")
+	expect.EQ(t, len(tt.errors), 0)
+	tt.finish()
+	expect.That(t, tt.errors, h.ElementsAre(h.AllOf(
+		h.HasPrefix("pending: 2 checks failed\n\n--- pending: failure 1 of 2 ---\n"),
+		h.Regexp(`(?s)Expected: +\(int\)2\n.*--- pending: failure 2 of 2 ---\n.*Expected: +\(string\)b`))))
+	expect.False(t, tt.failNow)
+
+	// A group that was done isn't reported again.
+	tt = &groupCleanupTester{}
+	g = expect.Group(tt, "done")
+	expect.EQ(g, 1, 2)
+	g.Done()
+	tt.finish()
+	expect.EQ(t, len(tt.errors), 1)
+}
+
+func TestGroupWithCallerSkip(t *testing.T) {
+	tt := &groupCleanupTester{}
+	g := expect.Group(expect.WithCallerSkip(tt, 1), "wrapped")
+	expect.EQ(g, 1, 2)
+	tt.finish()
+	expect.That(t, tt.errors, h.ElementsAre(h.HasPrefix("wrapped: 1 check failed\n")))
+}
@@ -1,20 +1,20 @@
 module github.com/grailbio/testutil
 
+go 1.21
+
 require (
 	github.com/aws/aws-sdk-go v1.23.22
-	github.com/creack/pty v1.1.9 // indirect
 	github.com/davecgh/go-spew v1.1.1
-	github.com/golang/protobuf v1.3.2 // indirect
-	github.com/google/go-cmp v0.3.1 // indirect
-	github.com/hashicorp/golang-lru v0.5.3 // indirect
-	github.com/kr/pty v1.1.8 // indirect
-	github.com/rogpeppe/go-internal v1.3.2 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/stretchr/objx v0.1.1 // indirect
 	github.com/stretchr/testify v1.4.0
-	gopkg.in/yaml.v2 v2.2.4 // indirect
-	honnef.co/go/tools v0.0.1-2019.2.3 // indirect
+	google.golang.org/protobuf v1.31.0
+	gopkg.in/yaml.v2 v2.2.4
 	v.io/x/lib v0.1.4
 )
 
+require (
+	github.com/jmespath/go-jmespath v0.0.0-20180206201540-c2b33e8439af // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+)
+
 replace git.apache.org/thrift.git => github.com/apache/thrift v0.12.0
@@ -0,0 +1,331 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/grailbio/testutil/h"
+)
+
+// UpdateGoldenEnv is the environment variable that, if set to a true value
+// such as "1", makes Golden rewrite the golden files instead of checking them.
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// updateGoldenFlag is the name of a flag that sets the update mode. This
+// package doesn't define it, since a test that defines a flag of the same name
+// would then panic; a test binary may define it as a boolean flag:
+//
+//   var _ = flag.Bool("update-golden", false, "rewrite the golden files")
+const updateGoldenFlag = "update-golden"
+
+// UpdatingGolden checks if the golden files are being rewritten, i.e., if the
+// test runs with UPDATE_GOLDEN=1, or with -update-golden if the test binary
+// defines that flag.
+func UpdatingGolden() bool {
+	if f := flag.Lookup(updateGoldenFlag); f != nil && f.Value.String() == "true" {
+		return true
+	}
+	switch strings.ToLower(os.Getenv(UpdateGoldenEnv)) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// Normalizer rewrites text before it is compared to a golden file, typically
+// to remove timestamps, paths or other spurious information.
+type Normalizer func(s string) string
+
+// Normalize chains normalizers: they are applied in order.
+func Normalize(ns ...Normalizer) Normalizer {
+	return func(s string) string {
+		for _, n := range ns {
+			s = n(s)
+		}
+		return s
+	}
+}
+
+// ReplaceRegexp creates a normalizer that replaces the matches of the regular
+// expression re by repl, which may refer to submatches as in
+// regexp.ReplaceAllString.
+//
+// Example:
+//   testutil.ReplaceRegexp(`\d{4}-\d\d-\d\dT[0-9:.]+Z`, "<TIME>")
+func ReplaceRegexp(re, repl string) Normalizer {
+	r := regexp.MustCompile(re)
+	return func(s string) string { return r.ReplaceAllString(s, repl) }
+}
+
+// TrimTrailingSpace is a normalizer that removes the spaces at the end of
+// every line.
+func TrimTrailingSpace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GoldenOptions configures NewGolden.
+type GoldenOptions struct {
+	// Dir is the directory that holds the golden files of all the tests. It
+	// defaults to "testdata/golden".
+	Dir string
+	// Normalizers are applied in order to both the output and the golden file
+	// before they are compared as text or JSON. The golden files are written
+	// without normalization.
+	Normalizers []Normalizer
+}
+
+// Golden checks the outputs of a test against golden files. The golden files
+// of a test are in a directory derived from its name: the files of test
+// TestFoo/bar are in <Dir>/TestFoo/bar. When the golden files are being
+// updated (see UpdatingGolden), the outputs are written to the golden files
+// instead.
+//
+// Example:
+//   g := testutil.NewGolden(t, testutil.GoldenOptions{
+//     Normalizers: []testutil.Normalizer{testutil.TrimTrailingSpace},
+//   })
+//   g.Text("report.txt", report)
+//   g.JSON("summary.json", summary)
+type Golden struct {
+	t         testing.TB
+	dir       string
+	normalize Normalizer
+}
+
+// NewGolden creates a Golden for the test t.
+func NewGolden(t testing.TB, opts GoldenOptions) *Golden {
+	dir := opts.Dir
+	if dir == "" {
+		dir = filepath.Join("testdata", "golden")
+	}
+	return &Golden{
+		t:         t,
+		dir:       filepath.Join(dir, goldenDirName(t.Name())),
+		normalize: Normalize(opts.Normalizers...),
+	}
+}
+
+// goldenDirName converts a test name to a relative directory path. Subtests
+// become subdirectories, and characters that are unsafe in paths are replaced
+// by "_".
+func goldenDirName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		part = strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+				return r
+			}
+			return '_'
+		}, part)
+		if part == "" || part == "." || part == ".." {
+			part = "_"
+		}
+		parts[i] = part
+	}
+	return filepath.Join(parts...)
+}
+
+// Dir returns the directory of the golden files of the test.
+func (g *Golden) Dir() string { return g.dir }
+
+// Path returns the path of the golden file name.
+func (g *Golden) Path(name string) string { return filepath.Join(g.dir, name) }
+
+// update writes the golden file name, and reports whether the golden files
+// are being updated.
+func (g *Golden) update(name string, data []byte) bool {
+	g.t.Helper()
+	if !UpdatingGolden() {
+		return false
+	}
+	path := g.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		g.t.Fatalf("golden: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		g.t.Fatalf("golden: %v", err)
+	}
+	g.t.Logf("updated golden file %s", path)
+	return true
+}
+
+// read reads the golden file name. It reports an error and returns false if
+// the file can't be read.
+func (g *Golden) read(name string) ([]byte, bool) {
+	g.t.Helper()
+	data, err := ioutil.ReadFile(g.Path(name))
+	if err != nil {
+		g.t.Errorf("golden: %v; rerun with %s=1 to create it", err, UpdateGoldenEnv)
+		return nil, false
+	}
+	return data, true
+}
+
+// mismatch reports that the output differs from the golden file name.
+func (g *Golden) mismatch(name, details string) {
+	g.t.Helper()
+	g.t.Errorf("golden file %s doesn't match; rerun with %s=1 to update it:\n%s",
+		g.Path(name), UpdateGoldenEnv, details)
+}
+
+// Text checks that got equals the golden file name, after normalization. On
+// mismatch, it reports a unified diff.
+func (g *Golden) Text(name, got string) {
+	g.t.Helper()
+	if g.update(name, []byte(got)) {
+		return
+	}
+	data, ok := g.read(name)
+	if !ok {
+		return
+	}
+	if d := h.UnifiedDiff("got", g.Path(name), g.normalize(got), g.normalize(string(data))); d != "" {
+		g.mismatch(name, d)
+	}
+}
+
+// JSON checks that got is equivalent to the JSON golden file name: the order
+// of object keys and the formatting don't matter. got is marshaled with
+// encoding/json, unless it is a []byte, a string or a json.RawMessage, which
+// hold JSON text. The golden file is written indented.
+func (g *Golden) JSON(name string, got interface{}) {
+	g.t.Helper()
+	var text []byte
+	switch v := got.(type) {
+	case []byte:
+		text = v
+	case json.RawMessage:
+		text = v
+	case string:
+		text = []byte(v)
+	default:
+		var err error
+		if text, err = json.Marshal(got); err != nil {
+			g.t.Fatalf("golden %s: %v", name, err)
+		}
+	}
+	_, gotIndented, err := canonicalJSON(text)
+	if err != nil {
+		g.t.Fatalf("golden %s: output is not valid JSON: %v", name, err)
+	}
+	if g.update(name, gotIndented) {
+		return
+	}
+	data, ok := g.read(name)
+	if !ok {
+		return
+	}
+	d, err := diffJSON(g.Path(name), gotIndented, data, g.normalize)
+	if err != nil {
+		g.t.Errorf("golden: %v", err)
+	} else if d != "" {
+		g.mismatch(name, d)
+	}
+}
+
+// diffJSON compares the JSON texts got and want semantically, after
+// normalizing their canonical forms, so that the normalizers see the same
+// layout for both. It returns "" if they are equivalent, or a unified diff of
+// the canonical forms. name is the name of want in the diff.
+func diffJSON(name string, got, want []byte, normalize Normalizer) (string, error) {
+	_, gotIndented, err := canonicalJSON(got)
+	if err != nil {
+		return "", fmt.Errorf("output is not valid JSON: %v", err)
+	}
+	_, wantIndented, err := canonicalJSON(want)
+	if err != nil {
+		return "", fmt.Errorf("%s is not valid JSON: %v", name, err)
+	}
+	gotN, wantN := normalize(string(gotIndented)), normalize(string(wantIndented))
+	gotValue, _, err := canonicalJSON([]byte(gotN))
+	if err != nil {
+		return "", fmt.Errorf("normalized output is not valid JSON: %v", err)
+	}
+	wantValue, _, err := canonicalJSON([]byte(wantN))
+	if err != nil {
+		return "", fmt.Errorf("%s is not valid JSON after normalization: %v", name, err)
+	}
+	if reflect.DeepEqual(gotValue, wantValue) {
+		return "", nil
+	}
+	return h.UnifiedDiff("got", name, gotN, wantN), nil
+}
+
+// canonicalJSON parses JSON text, which must hold a single value, and returns
+// its value and its indented form, with object keys sorted.
+func canonicalJSON(text []byte) (interface{}, []byte, error) {
+	d := json.NewDecoder(bytes.NewReader(text))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, nil, err
+	}
+	var extra interface{}
+	if err := d.Decode(&extra); err != io.EOF {
+		return nil, nil, fmt.Errorf("invalid data after top-level value")
+	}
+	indented, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, append(indented, '\n'), nil
+}
+
+// Binary checks that got is identical to the golden file name. Normalizers
+// don't apply. On mismatch, it reports the sizes and a hex dump of both around
+// the first difference.
+func (g *Golden) Binary(name string, got []byte) {
+	g.t.Helper()
+	if g.update(name, got) {
+		return
+	}
+	want, ok := g.read(name)
+	if !ok {
+		return
+	}
+	if d := diffBinary(got, want); d != "" {
+		g.mismatch(name, d)
+	}
+}
+
+// diffBinary returns "" if got and want are identical, or their sizes and a
+// hex dump of both around the first difference.
+func diffBinary(got, want []byte) string {
+	if bytes.Equal(got, want) {
+		return ""
+	}
+	offset := 0
+	for offset < len(got) && offset < len(want) && got[offset] == want[offset] {
+		offset++
+	}
+	const window = 32
+	start := offset / 16 * 16
+	dump := func(b []byte) string {
+		if start >= len(b) {
+			return "(end of data)\n"
+		}
+		end := start + window
+		if end > len(b) {
+			end = len(b)
+		}
+		return hex.Dump(b[start:end])
+	}
+	return fmt.Sprintf("got %d bytes, want %d bytes; first difference at offset %d (0x%x)\ngot (from offset 0x%x):\n%swant:\n%s",
+		len(got), len(want), offset, offset, start, dump(got), dump(want))
+}
@@ -0,0 +1,171 @@
+package testutil_test
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grailbio/testutil"
+	"github.com/grailbio/testutil/assert"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+// goldenTB records the errors reported to a test.
+type goldenTB struct {
+	testing.TB
+	name   string
+	errors []string
+}
+
+func (t *goldenTB) Helper()      {}
+func (t *goldenTB) Name() string { return t.name }
+
+func (t *goldenTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *goldenTB) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	panic("fatal")
+}
+
+func (t *goldenTB) Logf(format string, args ...interface{}) {}
+
+// updateGolden checks that a test binary can define the flag read by
+// UpdatingGolden.
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden files")
+
+func setUpdateGolden(t *testing.T, update bool) {
+	value := ""
+	if update {
+		value = "1"
+	}
+	t.Setenv(testutil.UpdateGoldenEnv, value)
+}
+
+func TestUpdatingGoldenFlag(t *testing.T) {
+	setUpdateGolden(t, false)
+	expect.False(t, testutil.UpdatingGolden())
+	assert.NoError(t, flag.Set("update-golden", "true"))
+	defer func() { *updateGolden = false }()
+	expect.True(t, testutil.UpdatingGolden())
+}
+
+func TestGoldenText(t *testing.T) {
+	dir := t.TempDir()
+	opts := testutil.GoldenOptions{
+		Dir:         dir,
+		Normalizers: []testutil.Normalizer{testutil.ReplaceRegexp(`\d+ms`, "<DURATION>"), testutil.TrimTrailingSpace},
+	}
+	tb := &goldenTB{TB: t, name: "TestReport/case #1"}
+	g := testutil.NewGolden(tb, opts)
+	expect.EQ(t, g.Dir(), filepath.Join(dir, "TestReport", "case__1"))
+
+	// A missing golden file is an error, unless the golden files are updated.
+	g.Text("report.txt", "ok in 10ms\n")
+	expect.That(t, tb.errors, h.ElementsAre(h.HasSubstr("rerun with UPDATE_GOLDEN=1 to create it")))
+
+	setUpdateGolden(t, true)
+	expect.True(t, testutil.UpdatingGolden())
+	g.Text("report.txt", "line 1\nok in 10ms\nline 3\n")
+	expect.That(t, g.Path("report.txt"), h.FileContains("line 1\nok in 10ms\nline 3\n"))
+
+	setUpdateGolden(t, false)
+	tb.errors = nil
+	g.Text("report.txt", "line 1  \nok in 25ms\nline 3\n")
+	expect.That(t, tb.errors, h.IsEmpty())
+
+	g.Text("report.txt", "line 1\nfailed in 25ms\nline 3\n")
+	expect.That(t, tb.errors, h.ElementsAre(h.HasSuffix(fmt.Sprintf(`to update it:
+--- got
++++ %s
+@@ -1,3 +1,3 @@
+ line 1
+-failed in <DURATION>
++ok in <DURATION>
+ line 3
+`, g.Path("report.txt")))))
+}
+
+func TestGoldenJSON(t *testing.T) {
+	dir := t.TempDir()
+	tb := &goldenTB{TB: t, name: "TestJSON"}
+	g := testutil.NewGolden(tb, testutil.GoldenOptions{
+		Dir:         dir,
+		Normalizers: []testutil.Normalizer{testutil.ReplaceRegexp(`"id": "[^"]*"`, `"id": "<ID>"`)},
+	})
+	type item struct {
+		ID    string `json:"id"`
+		Count int    `json:"count"`
+	}
+
+	setUpdateGolden(t, true)
+	g.JSON("items.json", []item{{"x1", 1}, {"x2", 2}})
+	expect.That(t, g.Path("items.json"), h.FileContains(`[
+  {
+    "count": 1,
+    "id": "x1"
+  },
+`))
+
+	setUpdateGolden(t, false)
+	g.JSON("items.json", `[{"id": "y1", "count": 1}, {"count": 2, "id": "y2"}]`)
+	expect.That(t, tb.errors, h.IsEmpty())
+
+	g.JSON("items.json", []item{{"x1", 1}, {"x2", 3}})
+	expect.That(t, tb.errors, h.ElementsAre(h.AllOf(
+		h.HasSubstr(`-    "count": 3,`),
+		h.HasSubstr(`+    "count": 2,`))))
+
+	tb.errors = nil
+	assert.NoError(t, ioutil.WriteFile(g.Path("bad.json"), []byte("{"), 0644))
+	g.JSON("bad.json", 1)
+	expect.That(t, tb.errors, h.ElementsAre(h.HasSubstr("bad.json is not valid JSON")))
+
+	// Data after the JSON value is an error, in the golden file and in the
+	// output.
+	tb.errors = nil
+	assert.NoError(t, ioutil.WriteFile(g.Path("bad.json"), []byte("1 2"), 0644))
+	g.JSON("bad.json", 1)
+	expect.That(t, tb.errors, h.ElementsAre(h.HasSubstr("bad.json is not valid JSON: invalid data after top-level value")))
+	tb.errors = nil
+	expect.Panics(t, func() { g.JSON("bad.json", "1}") }, h.Any())
+	expect.That(t, tb.errors, h.ElementsAre(h.HasSubstr("output is not valid JSON: invalid data after top-level value")))
+}
+
+func TestGoldenBinary(t *testing.T) {
+	tb := &goldenTB{TB: t, name: "TestBinary"}
+	g := testutil.NewGolden(tb, testutil.GoldenOptions{Dir: t.TempDir()})
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	setUpdateGolden(t, true)
+	g.Binary("data.bin", data)
+
+	setUpdateGolden(t, false)
+	g.Binary("data.bin", data)
+	expect.That(t, tb.errors, h.IsEmpty())
+
+	changed := append([]byte(nil), data...)
+	changed[40] = 0xff
+	g.Binary("data.bin", changed[:90])
+	expect.That(t, tb.errors, h.ElementsAre(h.AllOf(
+		h.HasSubstr("got 90 bytes, want 100 bytes; first difference at offset 40 (0x28)\ngot (from offset 0x20):\n"),
+		h.HasSubstr("00000000  20 21 22 23 24 25 26 27  ff 29"),
+		h.HasSubstr("00000000  20 21 22 23 24 25 26 27  28 29"))))
+}
+
+func TestCompareFileUpdate(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden.txt")
+	setUpdateGolden(t, true)
+	testutil.CompareFile(t, "a\nb\n", golden, nil)
+	setUpdateGolden(t, false)
+	testutil.CompareFile(t, "a \nb\n", golden, testutil.Normalize(testutil.TrimTrailingSpace))
+	_, err := os.Stat(golden)
+	expect.NoError(t, err)
+}
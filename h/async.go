@@ -0,0 +1,207 @@
+package h
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// producer checks that got is a function of form func() T, and returns it.
+func producer(label string, got interface{}) (reflect.Value, *Result) {
+	fn := reflect.ValueOf(got)
+	if fn.Kind() != reflect.Func || fn.Type().NumIn() != 0 || fn.Type().NumOut() != 1 {
+		r := NewErrorf(got, "%s: %s must be a function of form func() T", label, describeVerbose(got))
+		return fn, &r
+	}
+	return fn, nil
+}
+
+// receiver checks that got is a channel that can be received from, and
+// returns it.
+func receiver(label string, got interface{}) (reflect.Value, *Result) {
+	ch := reflect.ValueOf(got)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		r := NewErrorf(got, "%s: %s must be a receivable channel", label, describeVerbose(got))
+		return ch, &r
+	}
+	if ch.IsNil() {
+		r := NewErrorf(got, "%s: channel is nil", label)
+		return ch, &r
+	}
+	return ch, nil
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
+
+// pollDelay returns how long to wait before polling again: poll, unless less
+// than that remains before the deadline.
+func pollDelay(poll, remaining time.Duration) time.Duration {
+	if remaining < poll {
+		return remaining
+	}
+	return poll
+}
+
+// Eventually checks if the value produced by a function eventually matches
+// want. The target value must be a function of form func() T. It is invoked
+// every poll interval until the value it returns matches, or until the timeout
+// expires. On failure, the last value produced is reported. want can be an
+// immediate value or a Matcher.
+//
+// Example:
+//   assert.That(t, func() int { return counter.Load() }, h.Eventually(h.GE(10), time.Second, 10*time.Millisecond))
+func Eventually(want interface{}, timeout, poll time.Duration) *Matcher {
+	if poll <= 0 {
+		panic("h.Eventually: poll <= 0")
+	}
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("eventually %s within %v", phrasify(wm), timeout),
+		NotMsg: fmt.Sprintf("does not eventually %s within %v", phrasify(wm), timeout),
+	}
+	m.Match = func(got interface{}) Result {
+		fn, r := producer("Eventually", got)
+		if r != nil {
+			return *r
+		}
+		start := time.Now()
+		for {
+			val := fn.Call(nil)[0].Interface()
+			r := wm.Match(val)
+			switch r.status {
+			case DomainError:
+				return r
+			case Match:
+				return NewResult(true, val, m.Msg)
+			}
+			elapsed := time.Since(start)
+			if elapsed >= timeout {
+				return r.Wrap(val, m, fmt.Sprintf("which is the last value observed after waiting %v", roundDuration(elapsed)))
+			}
+			time.Sleep(pollDelay(poll, timeout-elapsed))
+		}
+	}
+	return m
+}
+
+// Consistently checks if the value produced by a function matches want for the
+// given duration. The target value must be a function of form func() T. It is
+// invoked every poll interval until the duration elapses, and the matcher
+// fails as soon as a value does not match. want can be an immediate value or a
+// Matcher.
+//
+// Example:
+//   assert.That(t, func() bool { return server.Healthy() }, h.Consistently(true, time.Second, 50*time.Millisecond))
+func Consistently(want interface{}, duration, poll time.Duration) *Matcher {
+	if poll <= 0 {
+		panic("h.Consistently: poll <= 0")
+	}
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("consistently %s for %v", phrasify(wm), duration),
+		NotMsg: fmt.Sprintf("does not consistently %s for %v", phrasify(wm), duration),
+	}
+	m.Match = func(got interface{}) Result {
+		fn, r := producer("Consistently", got)
+		if r != nil {
+			return *r
+		}
+		start := time.Now()
+		for {
+			val := fn.Call(nil)[0].Interface()
+			r := wm.Match(val)
+			elapsed := time.Since(start)
+			switch r.status {
+			case DomainError:
+				return r
+			case Mismatch:
+				return r.Wrap(val, m, fmt.Sprintf("which was observed after %v", roundDuration(elapsed)))
+			}
+			if elapsed >= duration {
+				return NewResult(true, val, m.Msg)
+			}
+			time.Sleep(pollDelay(poll, duration-elapsed))
+		}
+	}
+	return m
+}
+
+// Receives checks if a value can be received from a channel within the
+// timeout, and that the value matches want. The target value must be a
+// channel. The received value is consumed. want can be an immediate value or a
+// Matcher.
+//
+// Example:
+//   assert.That(t, doneCh, h.Receives(h.NoError(), time.Second))
+func Receives(want interface{}, timeout time.Duration) *Matcher {
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("channel receives a value that %s within %v", phrasify(wm), timeout),
+		NotMsg: fmt.Sprintf("channel does not receive a value that %s within %v", phrasify(wm), timeout),
+	}
+	m.Match = func(got interface{}) Result {
+		ch, r := receiver("Receives", got)
+		if r != nil {
+			return *r
+		}
+		start := time.Now()
+		chosen, val, ok := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(timeout))},
+		})
+		elapsed := roundDuration(time.Since(start))
+		if chosen == 1 {
+			r := NewResult(false, got, m.Msg)
+			r.valueAnnotations = append(r.valueAnnotations, fmt.Sprintf("which received nothing after waiting %v", elapsed))
+			return r
+		}
+		if !ok {
+			r := NewResult(false, got, m.Msg)
+			r.valueAnnotations = append(r.valueAnnotations, fmt.Sprintf("which was closed after waiting %v", elapsed))
+			return r
+		}
+		v := val.Interface()
+		switch r := wm.Match(v); r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(v, m, fmt.Sprintf("which was received after waiting %v", elapsed))
+		}
+		return NewResult(true, v, m.Msg)
+	}
+	return m
+}
+
+// IsClosed checks if a channel is closed. It does not block. If a value is
+// buffered in the channel, the channel is not closed, and the value is
+// consumed.
+//
+// Example:
+//   assert.That(t, doneCh, h.IsClosed())
+func IsClosed() *Matcher {
+	m := &Matcher{
+		Msg:    "channel is closed",
+		NotMsg: "channel is not closed",
+	}
+	m.Match = func(got interface{}) Result {
+		ch, r := receiver("IsClosed", got)
+		if r != nil {
+			return *r
+		}
+		chosen, val, ok := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectDefault},
+		})
+		if chosen == 0 && !ok {
+			return NewResult(true, got, m.Msg)
+		}
+		res := NewResult(false, got, m.Msg)
+		if chosen == 0 {
+			res.valueAnnotations = append(res.valueAnnotations, fmt.Sprintf("which had value %s", describe(val.Interface())))
+		}
+		return res
+	}
+	return m
+}
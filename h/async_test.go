@@ -0,0 +1,102 @@
+package h_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+type counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *counter) inc() {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+
+func (c *counter) get() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+func TestEventually(t *testing.T) {
+	c := &counter{}
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(time.Millisecond)
+			c.inc()
+		}
+	}()
+	expect.That(t, c.get, h.Eventually(5, 5*time.Second, time.Millisecond))
+
+	expect.That(t, h.Eventually(h.GT(5), 20*time.Millisecond, time.Millisecond).Match(c.get),
+		resultIs(h.Mismatch, `(?s)Actual: +\(int\)5 which is the last value observed after waiting \S+\nExpected: eventually > \(int\)5 within 20ms`))
+	expect.That(t, h.Eventually(h.GT(5), time.Millisecond, time.Millisecond).Match(10),
+		resultIs(h.DomainError, `must be a function of form func\(\) T`))
+	expect.That(t, h.Eventually(h.GT(5), time.Second, time.Millisecond).Match(func() string { return "x" }),
+		resultIs(h.DomainError, `not comparable`))
+
+	// The last poll happens at the deadline, even if the poll interval is
+	// longer.
+	start := time.Now()
+	expect.That(t, c.get, h.Not(h.Eventually(h.GT(5), 20*time.Millisecond, time.Hour)))
+	expect.That(t, time.Since(start), h.LT(time.Second))
+	expect.That(t, func() { h.Eventually(5, time.Second, 0) }, h.Panics(h.HasSubstr("poll <= 0")))
+}
+
+func TestConsistently(t *testing.T) {
+	c := &counter{}
+	expect.That(t, c.get, h.Consistently(0, 10*time.Millisecond, time.Millisecond))
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		c.inc()
+	}()
+	expect.That(t, h.Consistently(0, 5*time.Second, time.Millisecond).Match(c.get),
+		resultIs(h.Mismatch, `(?s)Actual: +\(int\)1 which was observed after \S+\nExpected: consistently is \(int\)0 for 5s`))
+
+	start := time.Now()
+	expect.That(t, c.get, h.Consistently(1, 20*time.Millisecond, time.Hour))
+	expect.That(t, time.Since(start), h.LT(time.Second))
+	expect.That(t, func() { h.Consistently(1, time.Second, -time.Millisecond) }, h.Panics(h.HasSubstr("poll <= 0")))
+}
+
+func TestReceives(t *testing.T) {
+	ch := make(chan int, 1)
+	ch <- 10
+	expect.That(t, ch, h.Receives(10, time.Second))
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		ch <- 11
+	}()
+	expect.That(t, ch, h.Receives(h.GT(10), time.Second))
+
+	expect.That(t, h.Receives(10, time.Millisecond).Match(ch),
+		resultIs(h.Mismatch, `which received nothing after waiting \S+`))
+	ch <- 12
+	expect.That(t, h.Receives(10, time.Millisecond).Match(ch),
+		resultIs(h.Mismatch, `(?s)Actual: +\(int\)12 which was received after waiting \S+\nExpected: channel receives a value that is \(int\)10`))
+	close(ch)
+	expect.That(t, h.Receives(10, time.Millisecond).Match(ch),
+		resultIs(h.Mismatch, `which was closed`))
+	expect.That(t, h.Receives(10, time.Millisecond).Match(make(chan<- int)),
+		resultIs(h.DomainError, `must be a receivable channel`))
+}
+
+func TestIsClosed(t *testing.T) {
+	ch := make(chan int, 1)
+	expect.That(t, ch, h.Not(h.IsClosed()))
+	ch <- 1
+	expect.That(t, h.IsClosed().Match(ch), resultIs(h.Mismatch, `which had value \(int\)1`))
+	close(ch)
+	expect.That(t, ch, h.IsClosed())
+	var nilCh chan int
+	expect.That(t, h.IsClosed().Match(nilCh), resultIs(h.DomainError, `channel is nil`))
+}
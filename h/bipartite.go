@@ -0,0 +1,79 @@
+package h
+
+// maxBipartiteMatching computes a maximum matching of a bipartite graph using
+// the Hopcroft-Karp algorithm. The left vertices are 0..len(adj)-1, and the
+// right vertices are 0..nRight-1. adj[i] lists the right vertices adjacent to
+// left vertex i.
+//
+// It returns matchL and matchR, where matchL[i] is the right vertex matched to
+// left vertex i, and matchR[j] is the left vertex matched to right vertex j.
+// Unmatched vertices are set to -1.
+//
+// The running time is O(E*sqrt(V)).
+func maxBipartiteMatching(adj [][]int, nRight int) (matchL, matchR []int) {
+	const inf = int(^uint(0) >> 1)
+	nLeft := len(adj)
+	matchL = make([]int, nLeft)
+	matchR = make([]int, nRight)
+	for i := range matchL {
+		matchL[i] = -1
+	}
+	for j := range matchR {
+		matchR[j] = -1
+	}
+	dist := make([]int, nLeft)
+
+	// bfs layers the left vertices by their distance from a free left vertex
+	// along alternating paths. It reports whether an augmenting path exists.
+	bfs := func() bool {
+		queue := make([]int, 0, nLeft)
+		for i := range adj {
+			if matchL[i] < 0 {
+				dist[i] = 0
+				queue = append(queue, i)
+			} else {
+				dist[i] = inf
+			}
+		}
+		found := false
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			for _, j := range adj[i] {
+				k := matchR[j]
+				if k < 0 {
+					found = true
+				} else if dist[k] == inf {
+					dist[k] = dist[i] + 1
+					queue = append(queue, k)
+				}
+			}
+		}
+		return found
+	}
+
+	// dfs finds an augmenting path from left vertex i that follows the layers
+	// computed by bfs, and flips the matching along it.
+	var dfs func(i int) bool
+	dfs = func(i int) bool {
+		for _, j := range adj[i] {
+			k := matchR[j]
+			if k < 0 || (dist[k] == dist[i]+1 && dfs(k)) {
+				matchL[i] = j
+				matchR[j] = i
+				return true
+			}
+		}
+		dist[i] = inf
+		return false
+	}
+
+	for bfs() {
+		for i := range adj {
+			if matchL[i] < 0 {
+				dfs(i)
+			}
+		}
+	}
+	return matchL, matchR
+}
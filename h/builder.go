@@ -0,0 +1,131 @@
+package h
+
+import (
+	"fmt"
+)
+
+// MatcherBuilder builds a custom Matcher. It takes care of the descriptions,
+// the annotations, and the propagation of DomainErrors, so that custom
+// matchers compose like the built-in ones. Create one with NewMatcherBuilder,
+// and finish it with Predicate or Delegate.
+//
+// Example:
+//   // A matcher that checks the status code of an *http.Response.
+//   func HasStatus(want interface{}) *h.Matcher {
+//     return h.NewMatcherBuilder("has status").
+//       Transform("whose status", func(got interface{}) (interface{}, error) {
+//         resp, ok := got.(*http.Response)
+//         if !ok {
+//           return nil, fmt.Errorf("%v is not an *http.Response", got)
+//         }
+//         return resp.StatusCode, nil
+//       }).
+//       Delegate(want)
+//   }
+//
+//   assert.That(t, resp, HasStatus(h.LT(300)))
+type MatcherBuilder struct {
+	msg, notMsg string
+	steps       []transformStep
+}
+
+// transformStep is a value transformation registered by Transform.
+type transformStep struct {
+	what string
+	fn   func(got interface{}) (interface{}, error)
+}
+
+// NewMatcherBuilder starts building a matcher with the given description. The
+// description completes the phrase "Expected: ...", e.g., "is even".
+func NewMatcherBuilder(msg string) *MatcherBuilder {
+	return &MatcherBuilder{msg: msg}
+}
+
+// Negated sets the description of the negation of the matcher, shown when the
+// matcher is wrapped in Not. By default, it is derived from the description.
+func (b *MatcherBuilder) Negated(notMsg string) *MatcherBuilder {
+	b.notMsg = notMsg
+	return b
+}
+
+// Transform adds a step that converts the value under test before it is
+// checked. Steps are applied in the order they are added. If fn returns an
+// error, the matcher reports a DomainError. what names the transformed value;
+// on mismatch, the value is annotated with "<what> <value> doesn't match",
+// e.g., "whose status 404 doesn't match".
+func (b *MatcherBuilder) Transform(what string, fn func(got interface{}) (interface{}, error)) *MatcherBuilder {
+	b.steps = append(b.steps, transformStep{what, fn})
+	return b
+}
+
+// Predicate finishes the matcher. The matcher succeeds if pred returns true
+// for the (transformed) value under test. The default negated description is
+// "not <description>".
+//
+// Example:
+//   isEven := h.NewMatcherBuilder("is even").Negated("is odd").
+//     Predicate(func(got interface{}) bool { return got.(int)%2 == 0 })
+func (b *MatcherBuilder) Predicate(pred func(got interface{}) bool) *Matcher {
+	notMsg := b.notMsg
+	if notMsg == "" {
+		notMsg = "not " + b.msg
+	}
+	m := &Matcher{Msg: b.msg, NotMsg: notMsg}
+	m.Match = func(got interface{}) Result {
+		return b.apply(m, got, func(v interface{}) Result {
+			return NewResult(pred(v), v, m.Msg)
+		})
+	}
+	return m
+}
+
+// Delegate finishes the matcher. The matcher applies want to the (transformed)
+// value under test. want can be an immediate value or a Matcher. The
+// description of the matcher is "<description> that <description of want>".
+func (b *MatcherBuilder) Delegate(want interface{}) *Matcher {
+	wm := toMatcher(want)
+	msg := fmt.Sprintf("%s that %s", b.msg, phrasify(wm))
+	notMsg := fmt.Sprintf("%s that %s", b.msg, wm.NotMsg)
+	if b.notMsg != "" {
+		notMsg = fmt.Sprintf("%s that %s", b.notMsg, phrasify(wm))
+	}
+	m := &Matcher{Msg: msg, NotMsg: notMsg}
+	m.Match = func(got interface{}) Result {
+		return b.apply(m, got, wm.Match)
+	}
+	return m
+}
+
+// apply runs the transformation steps on got, then check on the result. On
+// mismatch, the result is attributed to m, and annotated with the values
+// produced by the steps.
+func (b *MatcherBuilder) apply(m *Matcher, got interface{}, check func(v interface{}) Result) Result {
+	v := got
+	annots := make([]string, len(b.steps))
+	for i, step := range b.steps {
+		var err error
+		if v, err = step.fn(v); err != nil {
+			return NewErrorf(got, "%s: %v", m.Msg, err)
+		}
+		annots[i] = fmt.Sprintf("%s %s doesn't match", step.what, describe(v))
+	}
+	r := check(v)
+	switch r.status {
+	case DomainError:
+		return r
+	case Match:
+		return NewResult(true, got, m.Msg)
+	}
+	if len(annots) == 0 {
+		n := r
+		if r.msg != m.Msg {
+			n.nested = append(append([]string(nil), r.nested...), r.msg)
+		}
+		n.msg, n.value = m.Msg, got
+		return n
+	}
+	for i := len(annots) - 1; i >= 0; i-- {
+		r = r.Wrap(got, m, annots[i])
+	}
+	return r
+}
@@ -0,0 +1,77 @@
+package h_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+type httpReply struct {
+	status int
+	body   string
+}
+
+func hasStatus(want interface{}) *h.Matcher {
+	return h.NewMatcherBuilder("has status").
+		Transform("whose status", func(got interface{}) (interface{}, error) {
+			resp, ok := got.(httpReply)
+			if !ok {
+				return nil, fmt.Errorf("%v is not an httpReply", got)
+			}
+			return resp.status, nil
+		}).
+		Delegate(want)
+}
+
+func TestMatcherBuilderPredicate(t *testing.T) {
+	isEven := h.NewMatcherBuilder("is even").Negated("is odd").
+		Predicate(func(got interface{}) bool { return got.(int)%2 == 0 })
+	expect.That(t, 2, isEven)
+	expect.That(t, 3, h.Not(isEven))
+	expect.That(t, isEven.Match(3), resultIs(h.Mismatch, `Actual:   \(int\)3\nExpected: is even`))
+	expect.That(t, h.Not(isEven).Match(2), resultIs(h.Mismatch, `Expected: is odd`))
+
+	isPositive := h.NewMatcherBuilder("is positive").
+		Predicate(func(got interface{}) bool { return got.(int) > 0 })
+	expect.That(t, h.Not(isPositive).Match(2), resultIs(h.Mismatch, `Expected: not is positive`))
+}
+
+func TestMatcherBuilderDelegate(t *testing.T) {
+	resp := httpReply{404, "not found"}
+	expect.That(t, resp, hasStatus(404))
+	expect.That(t, resp, hasStatus(h.GE(400)))
+	expect.That(t, []httpReply{resp}, h.ElementsAre(hasStatus(404)))
+	expect.That(t, hasStatus(h.LT(300)).Match(resp),
+		resultIs(h.Mismatch, `whose status \(int\)404 doesn't match\nExpected: has status that is < \(int\)300`))
+	expect.That(t, h.Not(hasStatus(404)).Match(resp), resultIs(h.Mismatch, `Expected: has status that is != \(int\)404`))
+	expect.That(t, hasStatus(404).Match(10), resultIs(h.DomainError, `has status that is \(int\)404: 10 is not an httpReply`))
+	expect.That(t, hasStatus("x").Match(resp), resultIs(h.DomainError, "not comparable"))
+
+	// Annotations of the nested matcher are kept.
+	bodyWords := h.NewMatcherBuilder("has body words").
+		Transform("whose body", func(got interface{}) (interface{}, error) { return got.(httpReply).body, nil }).
+		Transform("whose words", func(got interface{}) (interface{}, error) { return strings.Fields(got.(string)), nil }).
+		Delegate(h.ElementsAre("not", "here"))
+	r := bodyWords.Match(resp)
+	expect.That(t, r, resultIs(h.Mismatch,
+		`whose body \(string\)not found doesn't match, whose words \(\[\]string\)\[not found\] doesn't match, whose element #1 doesn't match`))
+	expect.That(t, r.Value(), h.EQ(resp))
+	expect.That(t, r.Message(), h.HasPrefix("has body words that"))
+}
+
+func TestResultAccessors(t *testing.T) {
+	r := h.ElementsAre(1, 3).Match([]int{1, 2})
+	expect.EQ(t, r.Status(), h.Mismatch)
+	expect.EQ(t, r.Value(), []int{1, 2})
+	expect.That(t, r.Message(), h.HasPrefix("elements are"))
+	expect.That(t, r.Annotations(), h.ElementsAre(h.HasSubstr("#1")))
+	expect.That(t, r.Backtrace(), h.HasSubstr("builder_test.go"))
+
+	r = h.EQ(1).Match(1)
+	expect.EQ(t, r.Status(), h.Match)
+	expect.EQ(t, r.Backtrace(), "")
+	expect.That(t, r.Annotations(), h.IsEmpty())
+}
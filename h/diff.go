@@ -1,38 +1,494 @@
 package h
 
 import (
+	"bytes"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
 
 	"github.com/davecgh/go-spew/spew"
 )
 
-func diffStrings(got, want interface{}) string {
-	toString := func(v interface{}) string {
-		if s, ok := v.(string); ok {
-			return s
+const (
+	// maxDiffLines is the max number of differences reported by diffValues.
+	maxDiffLines = 50
+	// maxAlignCost is the max size of the table used to align two sequences.
+	// Above this limit, sequences are compared position by position.
+	maxAlignCost = 1 << 20
+	// diffContext is the number of unchanged lines shown around a change in a
+	// line diff.
+	diffContext = 3
+	// longStringLen is the length above which single-line strings are
+	// described by their first difference, since they are hard to compare by
+	// eye.
+	longStringLen = 80
+)
+
+// diffValues reports the differences between got and want. For structs, maps,
+// slices, arrays and pointers it produces one line per differing leaf, in the
+// form
+//
+//   .Foo.Bar[3]: got 5, want 6
+//
+// Strings are compared by diffStrings. It returns "" if the values are equal,
+// or if the only difference is at the top level and is therefore already shown
+// by Result.String.
+func diffValues(got, want interface{}) string { return diffValuesWith(got, want, nil) }
+
+// diffValuesWith is like diffValues, but it uses the equality defined by the
+// given EQWith options.
+func diffValuesWith(got, want interface{}, opts *eqOptions) string {
+	if gs, ok := got.(string); ok {
+		if ws, ok := want.(string); ok && gs != ws {
+			if sd := diffStrings(gs, ws); sd != "" {
+				return sd
+			}
+		}
+	}
+	d := &differ{opts: opts, visited: map[visit]bool{}}
+	d.diff("", addressable(reflect.ValueOf(got)), addressable(reflect.ValueOf(want)))
+	if len(d.lines) == 0 || (len(d.lines) == 1 && d.rootLeaf) {
+		return ""
+	}
+	return formatDiff(d.lines)
+}
+
+// formatDiff formats the differences found by diffValues or diffTrees, at
+// most maxDiffLines of them.
+func formatDiff(lines []string) string {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("Diff (got vs want):\n")
+	for i, line := range lines {
+		if i >= maxDiffLines {
+			buf.WriteString(fmt.Sprintf("  ... and %d more differences\n", len(lines)-maxDiffLines))
+			break
+		}
+		buf.WriteString("  ")
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+type differ struct {
+	opts     *eqOptions
+	lines    []string
+	visited  map[visit]bool
+	rootLeaf bool // the values differ only at the root, and they are scalars.
+}
+
+func (d *differ) report(path string, format string, args ...interface{}) {
+	if path == "" {
+		path = "value"
+	}
+	d.lines = append(d.lines, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (d *differ) leaf(path string, x, y reflect.Value) {
+	if path == "" {
+		d.rootLeaf = true
+	}
+	d.report(path, "got %s, want %s", describeValue(x), describeValue(y))
+}
+
+func (d *differ) diff(path string, x, y reflect.Value) {
+	x, y = readable(x), readable(y)
+	if !x.IsValid() || !y.IsValid() {
+		if x.IsValid() != y.IsValid() {
+			d.leaf(path, x, y)
+		}
+		return
+	}
+	c, err := newComparer(d.opts).compare(x, y)
+	if err == nil && c == cEQ {
+		return
+	}
+	if xt, yt, ok, _ := newComparer(d.opts).transform(x, y); ok {
+		if !xt.IsValid() || !yt.IsValid() {
+			d.leaf(path, xt, yt)
+			return
+		}
+		if c, err := newComparer(d.opts).compareTransformed(xt, yt); err == nil && c == cEQ {
+			return
+		}
+		// Descending into the transformed values would transform them again.
+		d.leaf(path, xt, yt)
+		return
+	}
+	if x.Type() != y.Type() {
+		if err != nil {
+			d.report(path, "got type %v, want type %v", x.Type(), y.Type())
+			return
+		}
+		d.leaf(path, x, y) // numbers of different sizes.
+		return
+	}
+	if _, ok := findComparator(d.opts, x.Type()); ok {
+		d.leaf(path, x, y)
+		return
+	}
+	switch x.Kind() {
+	case reflect.Ptr:
+		if x.IsNil() || y.IsNil() {
+			d.leaf(path, x, y)
+			return
+		}
+		v := visit{unsafe.Pointer(x.Pointer()), unsafe.Pointer(y.Pointer()), x.Type()}
+		if d.visited[v] {
+			return
+		}
+		d.visited[v] = true
+		d.diff(path, x.Elem(), y.Elem())
+	case reflect.Interface:
+		if x.IsNil() || y.IsNil() {
+			d.leaf(path, x, y)
+			return
+		}
+		if x.Elem().Type() != y.Elem().Type() {
+			d.report(path, "got type %v, want type %v", x.Elem().Type(), y.Elem().Type())
+			return
+		}
+		d.diff(path, addressable(x.Elem()), addressable(y.Elem()))
+	case reflect.Struct:
+		for i, n := 0, x.NumField(); i < n; i++ {
+			if d.opts != nil && d.opts.ignoreField(x.Type(), i) {
+				continue
+			}
+			d.diff(path+"."+x.Type().Field(i).Name, x.Field(i), y.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if x.Kind() == reflect.Slice && x.IsNil() != y.IsNil() {
+			d.leaf(path, x, y)
+			return
+		}
+		d.diffSequence(path, x, y)
+	case reflect.Map:
+		if x.IsNil() != y.IsNil() {
+			d.leaf(path, x, y)
+			return
+		}
+		d.diffMap(path, x, y)
+	case reflect.String:
+		sd := diffStrings(x.String(), y.String())
+		if sd == "" {
+			d.leaf(path, x, y)
+			return
+		}
+		d.report(path, "strings differ:")
+		for _, line := range strings.Split(strings.TrimSuffix(sd, "\n"), "\n") {
+			d.lines = append(d.lines, "  "+line)
+		}
+	default:
+		d.leaf(path, x, y)
+	}
+}
+
+// diffSequence reports the differences between two slices or arrays.  When the
+// lengths are the same the elements are compared position by position.
+// Otherwise the two sequences are aligned first so that a single inserted
+// element doesn't show up as a change in every subsequent position.
+func (d *differ) diffSequence(path string, x, y reflect.Value) {
+	nx, ny := x.Len(), y.Len()
+	if d.opts != nil && d.opts.ignoreOrder {
+		unmatchedX, unmatchedY, _ := newComparer(d.opts).pairElements(x, y)
+		for _, i := range unmatchedX {
+			d.report(fmt.Sprintf("%s[%d]", path, i), "got extra element %s", describeValue(x.Index(i)))
+		}
+		for _, j := range unmatchedY {
+			d.report(fmt.Sprintf("%s[%d]", path, j), "missing element %s", describeValue(y.Index(j)))
+		}
+		return
+	}
+	if nx == ny || nx*ny > maxAlignCost {
+		n := nx
+		if ny < n {
+			n = ny
+		}
+		for i := 0; i < n; i++ {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), x.Index(i), y.Index(i))
+		}
+		for i := n; i < nx; i++ {
+			d.report(fmt.Sprintf("%s[%d]", path, i), "got extra element %s", describeValue(x.Index(i)))
+		}
+		for i := n; i < ny; i++ {
+			d.report(fmt.Sprintf("%s[%d]", path, i), "missing element %s", describeValue(y.Index(i)))
+		}
+		return
+	}
+	eq := func(i, j int) bool {
+		c, err := newComparer(d.opts).compare(x.Index(i), y.Index(j))
+		return err == nil && c == cEQ
+	}
+	for _, op := range align(nx, ny, eq) {
+		switch op.kind {
+		case opDelete:
+			d.report(fmt.Sprintf("%s[%d]", path, op.x), "got extra element %s", describeValue(x.Index(op.x)))
+		case opInsert:
+			d.report(fmt.Sprintf("%s[%d]", path, op.y), "missing element %s", describeValue(y.Index(op.y)))
+		}
+	}
+}
+
+// diffMap reports the differences between two maps. Keys are visited in the
+// order of their descriptions so that the output is deterministic.
+func (d *differ) diffMap(path string, x, y reflect.Value) {
+	type entry struct {
+		desc string
+		key  reflect.Value
+	}
+	var keys []entry
+	for _, k := range x.MapKeys() {
+		keys = append(keys, entry{describeValue(k), k})
+	}
+	for _, k := range y.MapKeys() {
+		if !x.MapIndex(k).IsValid() {
+			keys = append(keys, entry{describeValue(k), k})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].desc < keys[j].desc })
+	for _, k := range keys {
+		p := fmt.Sprintf("%s[%s]", path, k.desc)
+		xv, yv := x.MapIndex(k.key), y.MapIndex(k.key)
+		switch {
+		case !yv.IsValid():
+			d.report(p, "got extra entry %s", describeValue(xv))
+		case !xv.IsValid():
+			d.report(p, "missing entry %s", describeValue(yv))
+		default:
+			d.diff(p, addressable(xv), addressable(yv))
 		}
-		return spew.Sdump(v)
 	}
-	tempDir, err := ioutil.TempDir("", "")
-	if err != nil {
-		panic(fmt.Sprintf("tempdir: %v", err))
+}
+
+// addressable returns a copy of v that is addressable, so that the values of
+// unexported fields reached from it can be read with readable.
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	p := reflect.New(v.Type()).Elem()
+	p.Set(v)
+	return p
+}
+
+// readable allows reading v through Interface() even if it was reached through
+// an unexported field. v must be addressable for this to work.
+func readable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// describeValue describes a value reached by reflection, possibly through
+// unexported fields.
+func describeValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
 	}
-	defer os.RemoveAll(tempDir) // nolint: errcheck
+	if v = readable(v); !v.CanInterface() {
+		return fmt.Sprintf("<%v>", v.Type())
+	}
+	if v.Kind() == reflect.String {
+		return strconv.Quote(v.String())
+	}
+	return spew.Sprintf("%+v", v.Interface())
+}
 
-	gotPath := filepath.Join(tempDir, "got")
-	wantPath := filepath.Join(tempDir, "want")
-	if err := ioutil.WriteFile(gotPath, []byte(toString(got)), 0600); err != nil {
-		panic(err)
+type editKind int
+
+const (
+	opKeep editKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is one step of an edit script that turns sequence x into sequence y.
+// For opKeep, x and y are both set. For opDelete only x is meaningful, and for
+// opInsert only y is.
+type edit struct {
+	kind editKind
+	x, y int
+}
+
+// align computes the shortest edit script from a sequence of length nx to a
+// sequence of length ny using a longest-common-subsequence table. eq(i, j)
+// reports whether x[i] and y[j] are equal.
+func align(nx, ny int, eq func(i, j int) bool) []edit {
+	// Skip the common prefix and suffix; they are often most of the input.
+	pre := 0
+	for pre < nx && pre < ny && eq(pre, pre) {
+		pre++
+	}
+	suf := 0
+	for suf < nx-pre && suf < ny-pre && eq(nx-1-suf, ny-1-suf) {
+		suf++
+	}
+	var edits []edit
+	for i := 0; i < pre; i++ {
+		edits = append(edits, edit{opKeep, i, i})
 	}
-	if err := ioutil.WriteFile(wantPath, []byte(toString(want)), 0600); err != nil {
-		panic(err)
+	mx, my := nx-pre-suf, ny-pre-suf
+	// lcs[i][j] is the length of the LCS of x[pre+i:] and y[pre+j:].
+	lcs := make([][]int, mx+1)
+	for i := range lcs {
+		lcs[i] = make([]int, my+1)
+	}
+	for i := mx - 1; i >= 0; i-- {
+		for j := my - 1; j >= 0; j-- {
+			switch {
+			case eq(pre+i, pre+j):
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < mx || j < my {
+		switch {
+		case i < mx && j < my && eq(pre+i, pre+j):
+			edits = append(edits, edit{opKeep, pre + i, pre + j})
+			i++
+			j++
+		case j >= my || (i < mx && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{opDelete, pre + i, -1})
+			i++
+		default:
+			edits = append(edits, edit{opInsert, -1, pre + j})
+			j++
+		}
+	}
+	for k := 0; k < suf; k++ {
+		edits = append(edits, edit{opKeep, nx - suf + k, ny - suf + k})
+	}
+	return edits
+}
+
+// diffStrings describes the differences between two strings that are hard to
+// compare by eye: multi-line strings get a line diff, and long strings get an
+// excerpt around the first difference. It returns "" for other strings.
+func diffStrings(got, want string) string {
+	switch {
+	case strings.Contains(got, "\n") || strings.Contains(want, "\n"):
+		return diffLines(got, want)
+	case len(got) > longStringLen || len(want) > longStringLen:
+		return describeStringDiff(got, want)
+	}
+	return ""
+}
+
+// diffLines produces a line diff of got and want in the unified format. Lines
+// only in got are prefixed by "-", lines only in want by "+".
+func diffLines(got, want string) string {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("--- got\n+++ want\n")
+	writeLineDiff(buf, got, want, [3]string{"  ", "- ", "+ "})
+	return buf.String()
+}
+
+// UnifiedDiff returns the differences between the lines of a and b in the
+// format of "diff -u", with aName and bName in the header. It returns "" if a
+// and b are equal.
+//
+// Example:
+//   if d := h.UnifiedDiff("got", "testdata/want.txt", got, want); d != "" {
+//     t.Error(d)
+//   }
+func UnifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	if strings.HasSuffix(a, "\n") && strings.HasSuffix(b, "\n") {
+		// Don't show the empty line after the last newline.
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", aName, bName))
+	writeLineDiff(buf, a, b, [3]string{" ", "-", "+"})
+	return buf.String()
+}
+
+// writeLineDiff writes the hunks of the line diff of got and want to buf. The
+// lines that are kept, deleted and inserted are prefixed by prefixes[0], [1]
+// and [2] respectively.
+func writeLineDiff(buf *bytes.Buffer, got, want string, prefixes [3]string) {
+	x, y := strings.Split(got, "\n"), strings.Split(want, "\n")
+	var edits []edit
+	if len(x)*len(y) > maxAlignCost {
+		// Too large to align; show the whole text as replaced.
+		for i := range x {
+			edits = append(edits, edit{opDelete, i, -1})
+		}
+		for j := range y {
+			edits = append(edits, edit{opInsert, -1, j})
+		}
+	} else {
+		edits = align(len(x), len(y), func(i, j int) bool { return x[i] == y[j] })
+	}
+
+	for start := 0; start < len(edits); {
+		// Find the next change, and extend the hunk until there are more than
+		// 2*diffContext unchanged lines in a row.
+		for start < len(edits) && edits[start].kind == opKeep {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		end := start
+		for keep := 0; end < len(edits) && keep <= 2*diffContext; end++ {
+			if edits[end].kind == opKeep {
+				keep++
+			} else {
+				keep = 0
+			}
+		}
+		for end > start && edits[end-1].kind == opKeep {
+			end--
+		}
+		lo, hi := start-diffContext, end+diffContext
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(edits) {
+			hi = len(edits)
+		}
+		writeHunk(buf, edits[lo:hi], x, y, prefixes)
+		start = hi
+	}
+}
+
+func writeHunk(buf *bytes.Buffer, edits []edit, x, y []string, prefixes [3]string) {
+	xStart, yStart, nx, ny := -1, -1, 0, 0
+	for _, e := range edits {
+		if e.kind != opInsert {
+			if xStart < 0 {
+				xStart = e.x
+			}
+			nx++
+		}
+		if e.kind != opDelete {
+			if yStart < 0 {
+				yStart = e.y
+			}
+			ny++
+		}
+	}
+	buf.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", xStart+1, nx, yStart+1, ny))
+	for _, e := range edits {
+		switch e.kind {
+		case opKeep:
+			buf.WriteString(prefixes[0] + x[e.x] + "\n")
+		case opDelete:
+			buf.WriteString(prefixes[1] + x[e.x] + "\n")
+		case opInsert:
+			buf.WriteString(prefixes[2] + y[e.y] + "\n")
+		}
 	}
-	cmd := exec.Command("diff", "-C", "3", gotPath, wantPath)
-	cmd.Stderr = os.Stderr
-	output, _ := cmd.Output()
-	return string(output)
 }
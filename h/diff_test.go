@@ -0,0 +1,137 @@
+package h_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestDiffStructPaths(t *testing.T) {
+	type inner struct {
+		Bar []int
+		baz string
+	}
+	type outer struct {
+		Foo   inner
+		Ptr   *inner
+		Attrs map[string]int
+	}
+	got := outer{
+		Foo:   inner{Bar: []int{1, 2, 3, 5}, baz: "x"},
+		Ptr:   &inner{baz: "y"},
+		Attrs: map[string]int{"a": 1, "b": 2},
+	}
+	want := outer{
+		Foo:   inner{Bar: []int{1, 2, 3, 6}, baz: "x"},
+		Ptr:   &inner{baz: "z"},
+		Attrs: map[string]int{"a": 1, "c": 3},
+	}
+	r := h.EQ(want).Match(got).String()
+	expect.HasSubstr(t, r, `Diff (got vs want):
+  .Foo.Bar[3]: got 5, want 6
+  .Ptr.baz: got "y", want "z"
+  .Attrs["b"]: got extra entry 2
+  .Attrs["c"]: missing entry 3
+`)
+}
+
+func TestDiffSequences(t *testing.T) {
+	expect.HasSubstr(t, h.EQ([]int{1, 2, 3}).Match([]int{1, 3}), "  [1]: missing element 2\n")
+	expect.HasSubstr(t, h.EQ([]int{1, 3}).Match([]int{1, 2, 3}), "  [1]: got extra element 2\n")
+}
+
+func TestDiffInterfaces(t *testing.T) {
+	type tt struct{ X interface{} }
+	expect.HasSubstr(t, h.EQ(tt{X: []string{"a"}}).Match(tt{X: []string{"b"}}), `  .X[0]: got "b", want "a"`)
+}
+
+func TestDiffScalarsHaveNoDiff(t *testing.T) {
+	r := h.EQ(42).Match(43).String()
+	expect.That(t, r, h.Not(h.HasSubstr("Diff (got vs want)")))
+	r = h.EQ("abc").Match("abd").String()
+	expect.That(t, r, h.Not(h.HasSubstr("Diff (got vs want)")))
+}
+
+func TestDiffCyclicValues(t *testing.T) {
+	type node struct {
+		Val  int
+		Next *node
+	}
+	x := &node{Val: 1}
+	x.Next = x
+	y := &node{Val: 2}
+	y.Next = y
+	expect.HasSubstr(t, h.EQ(y).Match(x), "  .Val: got 1, want 2\n")
+}
+
+func TestDiffTruncated(t *testing.T) {
+	var got, want []int
+	for i := 0; i < 100; i++ {
+		got = append(got, i)
+		want = append(want, -i-1)
+	}
+	expect.HasSubstr(t, h.EQ(want).Match(got), "  ... and 50 more differences\n")
+}
+
+func TestDiffStrings(t *testing.T) {
+	var got, want strings.Builder
+	for i := 0; i < 20; i++ {
+		got.WriteString(fmt.Sprintf("line%d\n", i))
+		want.WriteString(fmt.Sprintf("line%d\n", i))
+		if i == 10 {
+			want.WriteString("new line\n")
+		}
+	}
+	expect.HasSubstr(t, h.EQ(want.String()).Match(got.String()), `
+--- got
++++ want
+@@ -9,6 +9,7 @@
+  line8
+  line9
+  line10
++ new line
+  line11
+  line12
+  line13
+`)
+	type tt struct{ Body string }
+	expect.HasSubstr(t, h.EQ(tt{"a\nb\n"}).Match(tt{"a\nc\n"}), `.Body: strings differ:
+    --- got
+    +++ want
+    @@ -1,3 +1,3 @@
+      a
+    - c
+    + b
+`)
+
+	// Long single-line strings are described by their first difference.
+	long := strings.Repeat("abcdefghij", 30)
+	changed := long[:150] + "X" + long[151:]
+	expect.HasSubstr(t, h.EQ(long).Match(changed), `
+First difference at offset 150 (line 1, column 151):
+  got:  ..."abcdefghijabcdefghijXbcdefghijabcdefghij"...
+  want: ..."abcdefghijabcdefghijabcdefghijabcdefghij"...
+                                ^
+`)
+	expect.HasSubstr(t, h.EQ(tt{long}).Match(tt{changed}), `.Body: strings differ:
+    First difference at offset 150 (line 1, column 151):
+`)
+	r := h.EQ("short").Match("shirt")
+	expect.That(t, r.String(), h.Not(h.HasSubstr("First difference")))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	expect.EQ(t, h.UnifiedDiff("a", "b", "x\ny\n", "x\ny\n"), "")
+	expect.EQ(t, h.UnifiedDiff("old.txt", "new.txt", "a\nb\nc\n", "a\nB\nc\nd\n"), `--- old.txt
++++ new.txt
+@@ -1,3 +1,4 @@
+ a
+-b
++B
+ c
++d
+`)
+}
@@ -0,0 +1,361 @@
+package h
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// A tree is a value decoded from JSON or YAML. It is one of nil, bool, int64,
+// float64, string, []interface{} and map[string]interface{}.
+
+// decodeJSON parses a JSON document into a tree. Numbers that are integers are
+// decoded as int64, and other numbers as float64.
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return normalizeTree(v), nil
+}
+
+// decodeYAML parses a YAML document into a tree. Map keys are converted to
+// strings.
+func decodeYAML(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return normalizeTree(v), nil
+}
+
+// normalizeTree converts the values produced by the JSON and YAML decoders to
+// the types listed above.
+func normalizeTree(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case int:
+		return int64(v)
+	case uint64:
+		return float64(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeTree(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalizeTree(e)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalizeTree(e)
+		}
+		return m
+	}
+	return v
+}
+
+// describeTree describes a tree in compact JSON.
+func describeTree(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// toFloat converts a numeric tree node to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// treesEqual checks if two trees are equal. Numbers are compared by value, so
+// 1 equals 1.0.
+func treesEqual(x, y interface{}) bool {
+	var lines []string
+	diffTrees("$", x, y, &lines)
+	return len(lines) == 0
+}
+
+var identRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// treeKeyPath appends a map key to a JSONPath expression.
+func treeKeyPath(path, key string) string {
+	if identRE.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+}
+
+// diffTrees appends the differences between trees x (got) and y (want) to
+// lines. Each line starts with the JSONPath of the difference, e.g.
+//
+//   $.a.b[0]: got 1, want 2
+func diffTrees(path string, x, y interface{}, lines *[]string) {
+	report := func(format string, args ...interface{}) {
+		*lines = append(*lines, path+": "+fmt.Sprintf(format, args...))
+	}
+	switch xv := x.(type) {
+	case map[string]interface{}:
+		yv, ok := y.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(xv)+len(yv))
+		for k := range xv {
+			keys = append(keys, k)
+		}
+		for k := range yv {
+			if _, ok := xv[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			xe, xok := xv[k]
+			ye, yok := yv[k]
+			p := treeKeyPath(path, k)
+			switch {
+			case !yok:
+				*lines = append(*lines, fmt.Sprintf("%s: got extra entry %s", p, describeTree(xe)))
+			case !xok:
+				*lines = append(*lines, fmt.Sprintf("%s: missing entry %s", p, describeTree(ye)))
+			default:
+				diffTrees(p, xe, ye, lines)
+			}
+		}
+		return
+	case []interface{}:
+		yv, ok := y.([]interface{})
+		if !ok {
+			break
+		}
+		if len(xv) == len(yv) {
+			for i := range xv {
+				diffTrees(fmt.Sprintf("%s[%d]", path, i), xv[i], yv[i], lines)
+			}
+			return
+		}
+		eq := func(i, j int) bool { return treesEqual(xv[i], yv[j]) }
+		for _, op := range align(len(xv), len(yv), eq) {
+			switch op.kind {
+			case opDelete:
+				*lines = append(*lines, fmt.Sprintf("%s[%d]: got extra element %s", path, op.x, describeTree(xv[op.x])))
+			case opInsert:
+				*lines = append(*lines, fmt.Sprintf("%s[%d]: missing element %s", path, op.y, describeTree(yv[op.y])))
+			}
+		}
+		return
+	case int64, float64:
+		xf, _ := toFloat(x)
+		if yf, ok := toFloat(y); ok && xf == yf {
+			return
+		}
+	default:
+		if x == y {
+			return
+		}
+	}
+	report("got %s, want %s", describeTree(x), describeTree(y))
+}
+
+// encodedDoc extracts the document from a value under test, which must be a
+// string or a []byte.
+func encodedDoc(got interface{}) ([]byte, bool) {
+	switch got := got.(type) {
+	case string:
+		return []byte(got), true
+	case []byte:
+		return got, true
+	case json.RawMessage:
+		return got, true
+	}
+	return nil, false
+}
+
+// treeEq creates a matcher that decodes the value under test and compares it
+// to want.
+func treeEq(label, format string, want interface{}, decode func([]byte) (interface{}, error), encode func(interface{}) ([]byte, error)) *Matcher {
+	data, ok := encodedDoc(want)
+	if !ok {
+		var err error
+		if data, err = encode(want); err != nil {
+			panic(fmt.Sprintf("h.%s: cannot encode %s: %v", label, describeVerbose(want), err))
+		}
+	}
+	wantTree, err := decode(data)
+	if err != nil {
+		panic(fmt.Sprintf("h.%s: invalid %s %q: %v", label, format, data, err))
+	}
+	wantStr := describeTree(wantTree)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is %s equivalent to %s", format, wantStr),
+		NotMsg: fmt.Sprintf("is not %s equivalent to %s", format, wantStr),
+	}
+	m.Match = func(got interface{}) Result {
+		data, ok := encodedDoc(got)
+		if !ok {
+			return NewErrorf(got, "%s: %s must be a string or a []byte", label, describeVerbose(got))
+		}
+		gotTree, err := decode(data)
+		if err != nil {
+			return NewErrorf(string(data), "%s: invalid %s: %v", label, format, err)
+		}
+		var lines []string
+		diffTrees("$", gotTree, wantTree, &lines)
+		r := NewResult(len(lines) == 0, string(data), m.Msg)
+		if len(lines) > 0 {
+			r.extra = formatDiff(lines)
+		}
+		return r
+	}
+	return m
+}
+
+// JSONEq checks if the value is a JSON document that is semantically equal to
+// want. Key order and whitespace are ignored, and numbers are compared by
+// value. The target value must be a string or a []byte. want is either a
+// string or a []byte holding JSON, or a value that is converted to JSON with
+// json.Marshal. On mismatch, the differences are listed by their JSONPath.
+//
+// Example:
+//   assert.That(t, `{"b": [1, 2], "a": "x"}`, h.JSONEq(`{"a": "x", "b": [1, 2]}`))
+func JSONEq(want interface{}) *Matcher {
+	return treeEq("JSONEq", "JSON", want, decodeJSON, json.Marshal)
+}
+
+// YAMLEq checks if the value is a YAML document that is semantically equal to
+// want. It is the YAML counterpart of JSONEq; map keys are compared as
+// strings.
+//
+// Example:
+//   assert.That(t, "a: 1\nb: [x, y]\n", h.YAMLEq("b: [x, y]\na: 1"))
+func YAMLEq(want interface{}) *Matcher {
+	return treeEq("YAMLEq", "YAML", want, decodeYAML, yaml.Marshal)
+}
+
+// jsonPathStep is one component of a JSONPath: either an object key, or an
+// array index.
+type jsonPathStep struct {
+	isIndex bool
+	key     string
+	index   int
+}
+
+var jsonPathStepRE = regexp.MustCompile(`^(?:\.([A-Za-z_][A-Za-z0-9_-]*)|\[(\d+)\]|\['([^']*)'\]|\["((?:[^"\\]|\\.)*)"\])`)
+
+// parseJSONPath parses a JSONPath of form "$.a.b[0]['c d']".
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path must start with '$'")
+	}
+	var steps []jsonPathStep
+	for rest := path[1:]; rest != ""; {
+		m := jsonPathStepRE.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("invalid path component at %q", rest)
+		}
+		rest = rest[len(m[0]):]
+		switch {
+		case m[1] != "":
+			steps = append(steps, jsonPathStep{key: m[1]})
+		case m[2] != "":
+			i, err := strconv.Atoi(m[2])
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, jsonPathStep{isIndex: true, index: i})
+		case strings.HasPrefix(m[0], "['"):
+			steps = append(steps, jsonPathStep{key: m[3]})
+		default:
+			key, err := strconv.Unquote(`"` + m[4] + `"`)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, jsonPathStep{key: key})
+		}
+	}
+	return steps, nil
+}
+
+// JSONPath checks if the value is a JSON document that has a value at the
+// given path, and that the value matches want. The path is a simple JSONPath
+// expression made of object keys and array indexes, such as "$.a.b[0]" or
+// "$['a b'][1]". The target value must be a string or a []byte. JSON numbers
+// are passed to want as int64 if they are integers, and as float64 otherwise;
+// objects and arrays are passed as map[string]interface{} and []interface{}.
+//
+// Example:
+//   assert.That(t, `{"a": {"b": ["x", "y"]}}`, h.JSONPath("$.a.b[0]", "x"))
+//   assert.That(t, body, h.JSONPath("$.count", h.GT(int64(0))))
+func JSONPath(path string, want interface{}) *Matcher {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		panic(fmt.Sprintf("h.JSONPath: %q: %v", path, err))
+	}
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("has JSON value at %s that %s", path, phrasify(wm)),
+		NotMsg: fmt.Sprintf("does not have JSON value at %s that %s", path, phrasify(wm)),
+	}
+	m.Match = func(got interface{}) Result {
+		data, ok := encodedDoc(got)
+		if !ok {
+			return NewErrorf(got, "JSONPath: %s must be a string or a []byte", describeVerbose(got))
+		}
+		doc, err := decodeJSON(data)
+		if err != nil {
+			return NewErrorf(string(data), "JSONPath: invalid JSON: %v", err)
+		}
+		val := doc
+		for _, step := range steps {
+			found := false
+			if !step.isIndex {
+				if obj, ok := val.(map[string]interface{}); ok {
+					val, found = obj[step.key]
+				}
+			} else if arr, ok := val.([]interface{}); ok && step.index < len(arr) {
+				val, found = arr[step.index], true
+			}
+			if !found {
+				r := NewResult(false, string(data), m.Msg)
+				r.valueAnnotations = append(r.valueAnnotations, fmt.Sprintf("which has no value at %s", path))
+				return r
+			}
+		}
+		switch r := wm.Match(val); r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(string(data), m, fmt.Sprintf("whose value at %s %s doesn't match", path, describeTree(val)))
+		}
+		return NewResult(true, string(data), m.Msg)
+	}
+	return m
+}
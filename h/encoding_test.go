@@ -0,0 +1,60 @@
+package h_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestJSONEq(t *testing.T) {
+	expect.That(t, `{"b": [1, 2], "a": "x"}`, h.JSONEq(`{"a":"x","b":[1,2]}`))
+	expect.That(t, []byte(`{"a": 1.0}`), h.JSONEq(`{"a": 1}`))
+	expect.That(t, json.RawMessage(`{"a": null}`), h.JSONEq(map[string]interface{}{"a": nil}))
+	expect.That(t, `[1, 2]`, h.Not(h.JSONEq(`[2, 1]`)))
+
+	r := h.JSONEq(`{"a": {"b": [1, 2, 3], "c": "x"}, "d": true}`).Match(
+		`{"a": {"b": [1, 3], "c": "y", "e weird": 1}}`)
+	expect.That(t, r, resultIs(h.Mismatch, `Expected: is JSON equivalent to \{"a":\{"b":\[1,2,3\],"c":"x"\},"d":true\}`))
+	expect.HasSubstr(t, r, `Diff (got vs want):
+  $.a.b[1]: missing element 2
+  $.a.c: got "y", want "x"
+  $.a["e weird"]: got extra entry 1
+  $.d: missing entry true
+`)
+	expect.That(t, h.JSONEq(`1`).Match(`{`), resultIs(h.DomainError, "JSONEq: invalid JSON"))
+	expect.That(t, h.JSONEq(`1`).Match(`1 2`), resultIs(h.DomainError, "JSONEq: invalid JSON"))
+	expect.That(t, h.JSONEq(`{"a":1}`).Match(`{"a":1} }garbage`), resultIs(h.DomainError, "JSONEq: invalid JSON"))
+	expect.That(t, h.JSONEq(`[1,2]`).Match(`[1,2]]`), resultIs(h.DomainError, "JSONEq: invalid JSON"))
+	expect.That(t, `[1,2]`+"\n\t ", h.JSONEq(`[1,2]`))
+	expect.That(t, h.JSONEq(`1`).Match(1), resultIs(h.DomainError, "must be a string or a \\[\\]byte"))
+	expect.That(t, func() { h.JSONEq(`{`) }, h.Panics(h.HasSubstr("invalid JSON")))
+}
+
+func TestYAMLEq(t *testing.T) {
+	expect.That(t, "a: 1\nb: [x, y]\n", h.YAMLEq("b: [x, y]\na: 1"))
+	expect.That(t, "1: one", h.YAMLEq(map[string]string{"1": "one"}))
+	r := h.YAMLEq("a:\n  b: 1\n  c: [1, 2]\n").Match("a:\n  b: 2\n  c: [1, 2]\n")
+	expect.That(t, r, resultIs(h.Mismatch, `Expected: is YAML equivalent to \{"a":\{"b":1,"c":\[1,2\]\}\}`))
+	expect.HasSubstr(t, r, "  $.a.b: got 2, want 1\n")
+	expect.That(t, h.YAMLEq("a: 1").Match("a: [1"), resultIs(h.DomainError, "YAMLEq: invalid YAML"))
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := `{"a": {"b": ["x", "y"], "n": 3, "f": 1.5}, "c d": [{"e": null}]}`
+	expect.That(t, doc, h.JSONPath("$.a.b[0]", "x"))
+	expect.That(t, doc, h.JSONPath("$.a.b", h.ElementsAre("x", "y")))
+	expect.That(t, doc, h.JSONPath("$.a.n", h.GT(int64(2))))
+	expect.That(t, doc, h.JSONPath("$.a.f", 1.5))
+	expect.That(t, doc, h.JSONPath(`$['c d'][0]["e"]`, h.Nil()))
+	expect.That(t, doc, h.JSONPath("$", h.HasKey("a")))
+	expect.That(t, doc, h.Not(h.JSONPath("$.a.b[2]", h.Any())))
+
+	expect.That(t, h.JSONPath("$.a.b[1]", "x").Match(doc),
+		resultIs(h.Mismatch, `whose value at \$.a.b\[1\] "y" doesn't match\nExpected: has JSON value at \$.a.b\[1\] that is \(string\)x`))
+	expect.That(t, h.JSONPath("$.a.z", "x").Match(doc), resultIs(h.Mismatch, `which has no value at \$.a.z`))
+	expect.That(t, h.JSONPath("$.a", "x").Match("{"), resultIs(h.DomainError, "invalid JSON"))
+	expect.That(t, func() { h.JSONPath("a.b", 1) }, h.Panics(h.HasSubstr("must start with")))
+	expect.That(t, func() { h.JSONPath("$.a[x]", 1) }, h.Panics(h.HasSubstr("invalid path component")))
+}
@@ -0,0 +1,254 @@
+package h
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// errorChain lists err and the errors it wraps, in depth-first order. An error
+// wraps another if it implements Unwrap() error, Unwrap() []error, or if it is
+// an awserr.Error with a non-nil OrigErr.
+func errorChain(err error) []error {
+	var chain []error
+	var visit func(err error)
+	visit = func(err error) {
+		for err != nil {
+			chain = append(chain, err)
+			switch e := err.(type) {
+			case interface{ Unwrap() error }:
+				err = e.Unwrap()
+			case interface{ Unwrap() []error }:
+				for _, child := range e.Unwrap() {
+					visit(child)
+				}
+				return
+			case awserr.BatchedErrors:
+				for _, child := range e.OrigErrs() {
+					visit(child)
+				}
+				return
+			case awserr.Error:
+				err = e.OrigErr()
+			default:
+				return
+			}
+		}
+	}
+	visit(err)
+	return chain
+}
+
+// isError checks if err is considered the same as target, in the sense of
+// errors.Is.
+func isError(err, target error) bool {
+	if reflect.TypeOf(err).Comparable() && err == target {
+		return true
+	}
+	if e, ok := err.(interface{ Is(error) bool }); ok && e.Is(target) {
+		return true
+	}
+	return false
+}
+
+// toError checks that the value under test is an error. A nil value is
+// converted to a nil error.
+func toError(label string, got interface{}) (error, *Result) {
+	if got == nil {
+		return nil, nil
+	}
+	err, ok := got.(error)
+	if !ok {
+		r := NewErrorf(got, "%s: %s must be an error", label, describeVerbose(got))
+		return nil, &r
+	}
+	return err, nil
+}
+
+// ErrorIs checks if the value is an error that is, or wraps, target. An error
+// wraps another if it implements Unwrap, or if it is an awserr.Error with a
+// non-nil OrigErr. Like errors.Is, an error in the chain can customize the
+// check by implementing method Is(error) bool.
+//
+// Example:
+//   assert.That(t, err, h.ErrorIs(io.EOF))
+func ErrorIs(target error) *Matcher {
+	m := &Matcher{
+		Msg:    fmt.Sprintf("error that is or wraps %s", describe(target)),
+		NotMsg: fmt.Sprintf("error that neither is nor wraps %s", describe(target)),
+	}
+	m.Match = func(got interface{}) Result {
+		err, r := toError("ErrorIs", got)
+		if r != nil {
+			return *r
+		}
+		for _, e := range errorChain(err) {
+			if isError(e, target) {
+				return NewResult(true, got, m.Msg)
+			}
+		}
+		return NewResult(false, got, m.Msg)
+	}
+	return m
+}
+
+// ErrorAs checks if the value is an error that wraps an error assignable to the
+// value pointed to by target, and that the wrapped error matches want. On
+// success, the wrapped error is stored in *target, as in errors.As. target must
+// be a non-nil pointer to an interface or a type that implements error. want
+// can be an immediate value or a Matcher. Pass Any() to check only the type.
+//
+// Example:
+//   var perr *os.PathError
+//   assert.That(t, err, h.ErrorAs(&perr, h.Field("Op", "open")))
+func ErrorAs(target interface{}, want interface{}) *Matcher {
+	targetV := reflect.ValueOf(target)
+	if targetV.Kind() != reflect.Ptr || targetV.IsNil() {
+		panic(fmt.Sprintf("h.ErrorAs: target %s must be a non-nil pointer", describeVerbose(target)))
+	}
+	elemType := targetV.Type().Elem()
+	if elemType.Kind() != reflect.Interface && !elemType.Implements(errorType) {
+		panic(fmt.Sprintf("h.ErrorAs: *target must be an interface or implement error, but it is %v", elemType))
+	}
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("error that wraps a %v that %s", elemType, phrasify(wm)),
+		NotMsg: fmt.Sprintf("error that doesn't wrap a %v that %s", elemType, phrasify(wm)),
+	}
+	m.Match = func(got interface{}) Result {
+		err, r := toError("ErrorAs", got)
+		if r != nil {
+			return *r
+		}
+		for _, e := range errorChain(err) {
+			if reflect.TypeOf(e).AssignableTo(elemType) {
+				targetV.Elem().Set(reflect.ValueOf(e))
+			} else if ae, ok := e.(interface{ As(interface{}) bool }); !ok || !ae.As(target) {
+				continue
+			}
+			val := targetV.Elem().Interface()
+			r := wm.Match(val)
+			switch r.status {
+			case DomainError:
+				return r
+			case Mismatch:
+				return r.Wrap(got, m, fmt.Sprintf("whose wrapped %v %s doesn't match", elemType, describe(val)))
+			}
+			return NewResult(true, got, m.Msg)
+		}
+		return NewResult(false, got, m.Msg)
+	}
+	return m
+}
+
+// ErrorMessage checks if the value is a non-nil error whose Error() string
+// matches want. want can be an immediate string or a Matcher.
+//
+// Example:
+//   assert.That(t, err, h.ErrorMessage(h.HasSubstr("not found")))
+func ErrorMessage(want interface{}) *Matcher {
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("error whose message %s", phrasify(wm)),
+		NotMsg: fmt.Sprintf("error whose message %s", wm.NotMsg),
+	}
+	m.Match = func(got interface{}) Result {
+		err, r := toError("ErrorMessage", got)
+		if r != nil {
+			return *r
+		}
+		if err == nil {
+			return NewResult(false, got, m.Msg)
+		}
+		msg := err.Error()
+		switch r := wm.Match(msg); r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(got, m, fmt.Sprintf("whose message %q doesn't match", msg))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// ErrorChainContains checks if the value is an error, and that every one of
+// wants matches the error or one of the errors it wraps. A want is either an
+// error, which is matched as in ErrorIs, or a Matcher that is applied to every
+// error in the chain.
+//
+// Example:
+//   assert.That(t, err, h.ErrorChainContains(context.Canceled, h.ErrorMessage(h.HasPrefix("read"))))
+func ErrorChainContains(wants ...interface{}) *Matcher {
+	matchers := make([]*Matcher, len(wants))
+	msgs := make([]string, len(wants))
+	for i, w := range wants {
+		if err, ok := w.(error); ok {
+			matchers[i] = ErrorIs(err)
+		} else {
+			matchers[i] = toMatcher(w)
+		}
+		msgs[i] = matchers[i].Msg
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("error chain contains [%s]", strings.Join(msgs, ", ")),
+		NotMsg: fmt.Sprintf("error chain doesn't contain all of [%s]", strings.Join(msgs, ", ")),
+	}
+	m.Match = func(got interface{}) Result {
+		err, r := toError("ErrorChainContains", got)
+		if r != nil {
+			return *r
+		}
+		chain := errorChain(err)
+		for i, want := range matchers {
+			found := false
+			for _, e := range chain {
+				r := want.Match(e)
+				if r.status == DomainError {
+					return r
+				}
+				if r.status == Match {
+					found = true
+					break
+				}
+			}
+			if !found {
+				r := NewResult(false, got, m.Msg)
+				r.valueAnnotations = append(r.valueAnnotations, fmt.Sprintf("which has no error that matches #%d (%s)", i, msgs[i]))
+				return r
+			}
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// AWSErrorCode checks if the value is an awserr.Error with the given code, or
+// an error that wraps one.
+//
+// Example:
+//   _, err := s3client.GetObject(&s3.GetObjectInput{...})
+//   expect.That(t, err, h.AWSErrorCode("NoSuchKey"))
+func AWSErrorCode(code string) *Matcher {
+	m := &Matcher{
+		Msg:    fmt.Sprintf("AWS error with code %s", code),
+		NotMsg: fmt.Sprintf("not an AWS error with code %s", code),
+	}
+	m.Match = func(got interface{}) Result {
+		err, r := toError("AWSErrorCode", got)
+		if r != nil {
+			return *r
+		}
+		for _, e := range errorChain(err) {
+			if ae, ok := e.(awserr.Error); ok && ae.Code() == code {
+				return NewResult(true, got, m.Msg)
+			}
+		}
+		return NewResult(false, got, m.Msg)
+	}
+	return m
+}
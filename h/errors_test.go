@@ -0,0 +1,83 @@
+package h_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+	"github.com/grailbio/testutil/s3test"
+)
+
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string { return e.msg + ": " + e.err.Error() }
+func (e *wrapError) Unwrap() error { return e.err }
+
+type codeError struct{ code int }
+
+func (e codeError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestErrorIs(t *testing.T) {
+	err := &wrapError{"read", &wrapError{"open", io.EOF}}
+	expect.That(t, err, h.ErrorIs(io.EOF))
+	expect.That(t, io.EOF, h.ErrorIs(io.EOF))
+	expect.That(t, err, h.Not(h.ErrorIs(io.ErrUnexpectedEOF)))
+	expect.That(t, nil, h.Not(h.ErrorIs(io.EOF)))
+	expect.That(t, awserr.New("InternalError", "oops", io.EOF), h.ErrorIs(io.EOF))
+	expect.That(t, h.ErrorIs(io.EOF).Match(io.ErrClosedPipe),
+		resultIs(h.Mismatch, "Expected: error that is or wraps"))
+	expect.That(t, h.ErrorIs(io.EOF).Match(10), resultIs(h.DomainError, "must be an error"))
+}
+
+func TestErrorAs(t *testing.T) {
+	var cerr codeError
+	err := &wrapError{"read", codeError{404}}
+	expect.That(t, err, h.ErrorAs(&cerr, h.Field("code", 404)))
+	expect.EQ(t, cerr.code, 404)
+	expect.That(t, err, h.ErrorAs(&cerr, h.Any()))
+	expect.That(t, h.ErrorAs(&cerr, h.Field("code", 500)).Match(err),
+		resultIs(h.Mismatch, `whose wrapped h_test.codeError .* doesn't match, whose field code \(int\)404 doesn't match`))
+
+	var perr *os.PathError
+	expect.That(t, err, h.Not(h.ErrorAs(&perr, h.Any())))
+	_, err2 := os.Open("/non/existent")
+	expect.That(t, &wrapError{"x", err2}, h.ErrorAs(&perr, h.Field("Op", "open")))
+
+	expect.That(t, func() { h.ErrorAs(cerr, h.Any()) }, h.Panics(h.HasSubstr("must be a non-nil pointer")))
+	expect.That(t, func() { h.ErrorAs(new(int), h.Any()) }, h.Panics(h.HasSubstr("must be an interface or implement error")))
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := &wrapError{"read", io.EOF}
+	expect.That(t, err, h.ErrorMessage("read: EOF"))
+	expect.That(t, err, h.ErrorMessage(h.HasPrefix("read")))
+	expect.That(t, nil, h.Not(h.ErrorMessage(h.Any())))
+	expect.That(t, h.ErrorMessage(h.Regexp("x$")).Match(err),
+		resultIs(h.Mismatch, `whose message "read: EOF" doesn't match`))
+}
+
+func TestErrorChainContains(t *testing.T) {
+	err := &wrapError{"read", &wrapError{"open", codeError{1}}}
+	expect.That(t, err, h.ErrorChainContains(codeError{1}, h.ErrorMessage(h.HasPrefix("open"))))
+	expect.That(t, h.ErrorChainContains(codeError{1}, codeError{2}).Match(err),
+		resultIs(h.Mismatch, `which has no error that matches #1`))
+}
+
+func TestAWSErrorCode(t *testing.T) {
+	client := s3test.NewClient(t, "bucket")
+	_, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")})
+	expect.That(t, err, h.AWSErrorCode("NoSuchKey"))
+	expect.That(t, err, h.Not(h.AWSErrorCode("AccessDenied")))
+	expect.That(t, &wrapError{"get", err}, h.AWSErrorCode("NoSuchKey"))
+	expect.That(t, awserr.NewRequestFailure(awserr.New("SlowDown", "", nil), 503, ""), h.AWSErrorCode("SlowDown"))
+	expect.That(t, io.EOF, h.Not(h.AWSErrorCode("NoSuchKey")))
+}
@@ -0,0 +1,77 @@
+package h
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// pathOf converts the value under test to a file path. It must be a string.
+func pathOf(label string, got interface{}) (string, *Result) {
+	path, ok := got.(string)
+	if !ok {
+		r := NewErrorf(got, "%s: %s must be a file path", label, describeVerbose(got))
+		return "", &r
+	}
+	return path, nil
+}
+
+// FileExists checks if the value is the path of an existing file or directory.
+//
+// Example:
+//   assert.That(t, filepath.Join(dir, "out.txt"), h.FileExists())
+func FileExists() *Matcher {
+	m := &Matcher{Msg: "is an existing file", NotMsg: "is not an existing file"}
+	m.Match = func(got interface{}) Result {
+		path, r := pathOf("FileExists", got)
+		if r != nil {
+			return *r
+		}
+		_, err := os.Stat(path)
+		if err != nil && !os.IsNotExist(err) {
+			return NewErrorf(got, "FileExists: %v", err)
+		}
+		return NewResult(err == nil, got, m.Msg)
+	}
+	return m
+}
+
+// FileContains checks if the value is the path of a file whose contents match
+// want. If want is a string, the file must contain it as a substring.
+// Otherwise, want is a matcher or a value, which is applied to the contents of
+// the file as a string. It is a DomainError if the file can't be read.
+//
+// Example:
+//   assert.That(t, logPath, h.FileContains("server started"))
+//   assert.That(t, outPath, h.FileContains(h.Lines(h.Len(3))))
+func FileContains(want interface{}) *Matcher {
+	var wm *Matcher
+	if s, ok := want.(string); ok {
+		wm = HasSubstr(s)
+	} else {
+		wm = toMatcher(want)
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is a file whose contents %s", phrasify(wm)),
+		NotMsg: fmt.Sprintf("is not a file whose contents %s", phrasify(wm)),
+	}
+	m.Match = func(got interface{}) Result {
+		path, r := pathOf("FileContains", got)
+		if r != nil {
+			return *r
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return NewErrorf(got, "FileContains: %v", err)
+		}
+		contents := string(data)
+		switch r := wm.Match(contents); r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(got, m, fmt.Sprintf("whose contents %q don't match", contents))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
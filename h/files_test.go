@@ -0,0 +1,32 @@
+package h_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grailbio/testutil/assert"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestFileMatchers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "files_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.txt")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("hello\nworld\n"), 0600))
+
+	expect.That(t, path, h.FileExists())
+	expect.That(t, dir, h.FileExists())
+	expect.That(t, filepath.Join(dir, "missing"), h.Not(h.FileExists()))
+	expect.That(t, h.FileExists().Match(10), resultIs(h.DomainError, "FileExists: .* must be a file path"))
+
+	expect.That(t, path, h.FileContains("world"))
+	expect.That(t, path, h.FileContains(h.Lines(h.ElementsAre("hello", "world"))))
+	expect.That(t, path, h.Not(h.FileContains("bye")))
+	expect.That(t, h.FileContains("bye").Match(path),
+		resultIs(h.Mismatch, `whose contents "hello\\nworld\\n" don't match\nExpected: is a file whose contents has substring`))
+	expect.That(t, h.FileContains("x").Match(filepath.Join(dir, "missing")), resultIs(h.DomainError, "FileContains: .*no such file"))
+}
@@ -0,0 +1,140 @@
+package h
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+)
+
+// Of is a matcher for values of type T. Unlike *Matcher, which accepts any
+// value and reports a DomainError for a value of the wrong type, an Of[T] only
+// accepts values of type T, so type mistakes are caught at compile time. An
+// Of[T] wraps a *Matcher, so the two can be mixed freely: As converts a
+// *Matcher to an Of[T], and Of[T].Matcher converts it back.
+//
+// Example:
+//   isAdult := h.Satisfies("is an adult", func(p Person) bool { return p.Age >= 18 })
+//   expect.ThatOf(t, alice, isAdult)
+//   expect.That(t, []Person{alice, bob}, h.Each(isAdult.Matcher()))
+type Of[T any] struct {
+	m *Matcher
+}
+
+// As declares that the matcher, or the immediate value, want checks values of
+// type T. It is the migration path from *Matcher to Of[T].
+//
+// Example:
+//   expect.ThatOf(t, name, h.As[string](h.HasPrefix("Dr. ")))
+func As[T any](want interface{}) Of[T] {
+	return Of[T]{toMatcher(want)}
+}
+
+// Matcher returns the underlying *Matcher, for use with the matchers that take
+// interface{} values, e.g., h.AllOf or h.Each.
+func (o Of[T]) Matcher() *Matcher { return o.m }
+
+// Match checks if got satisfies the matcher.
+func (o Of[T]) Match(got T) Result { return o.m.Match(got) }
+
+// typedValue converts got to T. It reports a DomainError if got is of another
+// type, which happens only when the matcher is used through Matcher.
+func typedValue[T any](label string, got interface{}) (T, *Result) {
+	v, ok := got.(T)
+	if !ok && got == nil && reflect.TypeOf((*T)(nil)).Elem().Kind() == reflect.Interface {
+		ok = true // A nil interface.
+	}
+	if !ok {
+		r := NewErrorf(got, "%s: %s must be of type %v", label, describeVerbose(got), reflect.TypeOf((*T)(nil)).Elem())
+		return v, &r
+	}
+	return v, nil
+}
+
+// Equal checks if the value equals want, like EQ.
+func Equal[T any](want T) Of[T] {
+	return Of[T]{EQ(want)}
+}
+
+// Less checks if the value is < want, like LT.
+func Less[T cmp.Ordered](want T) Of[T] {
+	return Of[T]{LT(want)}
+}
+
+// Greater checks if the value is > want, like GT.
+func Greater[T cmp.Ordered](want T) Of[T] {
+	return Of[T]{GT(want)}
+}
+
+// Satisfies creates a matcher that checks if pred returns true for the value.
+// msg describes the condition, e.g., "is even". The negated description is
+// "not <msg>".
+//
+// Example:
+//   isEven := h.Satisfies("is even", func(v int) bool { return v%2 == 0 })
+func Satisfies[T any](msg string, pred func(v T) bool) Of[T] {
+	m := &Matcher{Msg: msg, NotMsg: "not " + msg}
+	m.Match = func(got interface{}) Result {
+		v, r := typedValue[T](msg, got)
+		if r != nil {
+			return *r
+		}
+		return NewResult(pred(v), got, m.Msg)
+	}
+	return Of[T]{m}
+}
+
+// Map creates a matcher that converts the value with fn, and checks the result
+// with want. what names the converted value, e.g., "whose name"; on mismatch,
+// the value is annotated with "<what> <converted value> doesn't match".
+//
+// Example:
+//   hasName := func(want h.Of[string]) h.Of[Person] {
+//     return h.Map("whose name", func(p Person) string { return p.Name }, want)
+//   }
+//   expect.ThatOf(t, alice, hasName(h.Equal("Alice")))
+func Map[T, U any](what string, fn func(v T) U, want Of[U]) Of[T] {
+	m := &Matcher{
+		Msg:    fmt.Sprintf("%s %s", what, phrasify(want.m)),
+		NotMsg: fmt.Sprintf("%s %s", what, want.m.NotMsg),
+	}
+	m.Match = func(got interface{}) Result {
+		v, r := typedValue[T](m.Msg, got)
+		if r != nil {
+			return *r
+		}
+		u := fn(v)
+		switch r := want.m.Match(u); r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(got, m, fmt.Sprintf("%s %s doesn't match", what, describe(u)))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return Of[T]{m}
+}
+
+// Not negates the matcher.
+func (o Of[T]) Not() Of[T] {
+	return Of[T]{Not(o.m)}
+}
+
+// And creates a matcher that succeeds if o and all the others succeed, like
+// AllOf.
+func (o Of[T]) And(others ...Of[T]) Of[T] {
+	ms := []interface{}{o.m}
+	for _, other := range others {
+		ms = append(ms, other.m)
+	}
+	return Of[T]{AllOf(ms...)}
+}
+
+// Or creates a matcher that succeeds if o or any of the others succeeds, like
+// AnyOf.
+func (o Of[T]) Or(others ...Of[T]) Of[T] {
+	ms := []interface{}{o.m}
+	for _, other := range others {
+		ms = append(ms, other.m)
+	}
+	return Of[T]{AnyOf(ms...)}
+}
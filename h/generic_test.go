@@ -0,0 +1,42 @@
+package h_test
+
+import (
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+type person struct {
+	Name string
+	Age  int
+}
+
+func hasAge(want h.Of[int]) h.Of[person] {
+	return h.Map("whose age", func(p person) int { return p.Age }, want)
+}
+
+func TestGenericMatchers(t *testing.T) {
+	alice := person{"Alice", 30}
+	expect.ThatOf(t, alice, h.Equal(person{"Alice", 30}))
+	expect.ThatOf(t, alice, hasAge(h.Less(65).And(h.Greater(18))))
+	expect.ThatOf(t, alice, hasAge(h.Equal(40)).Not())
+	expect.ThatOf(t, alice, hasAge(h.Equal(40)).Or(hasAge(h.Equal(30))))
+	expect.ThatOf(t, "abc", h.As[string](h.HasPrefix("ab")))
+	expect.ThatOf(t, 5, h.Satisfies("is odd", func(v int) bool { return v%2 == 1 }))
+	expect.ThatOf[error](t, nil, h.Satisfies("is nil", func(err error) bool { return err == nil }))
+
+	expect.That(t, hasAge(h.Less(18)).Match(alice),
+		resultIs(h.Mismatch, `whose age \(int\)30 doesn't match\nExpected: whose age is < \(int\)18`))
+	expect.That(t, h.Satisfies("is odd", func(v int) bool { return v%2 == 1 }).Match(4),
+		resultIs(h.Mismatch, `Expected: is odd`))
+}
+
+func TestGenericInterop(t *testing.T) {
+	isAdult := h.Satisfies("is an adult", func(p person) bool { return p.Age >= 18 })
+	people := []person{{"Alice", 30}, {"Bob", 20}}
+	expect.That(t, people, h.Each(isAdult.Matcher()))
+	expect.That(t, people, h.Contains(hasAge(h.Equal(20)).Matcher()))
+	// A typed matcher used through *Matcher reports values of the wrong type.
+	expect.That(t, isAdult.Matcher().Match(30), resultIs(h.DomainError, `is an adult: .* must be of type h_test.person`))
+}
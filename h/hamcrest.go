@@ -2,10 +2,10 @@
 // documentations in assert and expect for more details.
 package h
 
-//go:generate sh -c "sed -e s/PACKAGE/assert/ utils.go.tpl > ../assert/utils.go"
-//go:generate sh -c "sed -e s/PACKAGE/assert/ utils_test.go.tpl > ../assert/utils_test.go"
-//go:generate sh -c "sed -e s/PACKAGE/expect/ utils.go.tpl > ../expect/utils.go"
-//go:generate sh -c "sed -e s/PACKAGE/expect/ utils_test.go.tpl > ../expect/utils_test.go"
+//go:generate sh -c "sed -e s/PACKAGE/assert/g utils.go.tpl > ../assert/utils.go"
+//go:generate sh -c "sed -e s/PACKAGE/assert/g utils_test.go.tpl > ../assert/utils_test.go"
+//go:generate sh -c "sed -e s/PACKAGE/expect/g utils.go.tpl > ../expect/utils.go"
+//go:generate sh -c "sed -e s/PACKAGE/expect/g utils_test.go.tpl > ../expect/utils_test.go"
 
 import (
 	"bytes"
@@ -47,24 +47,64 @@ func (s Status) String() string {
 type Result struct {
 	// Status is the match result.
 	status Status
-	// Backtrace is the stack backtrace when the matcher ran.
-	backtrace string
+	// Backtrace is the stack backtrace when the matcher ran. It is formatted
+	// lazily, since most mismatches of nested matchers are never printed.
+	backtrace []uintptr
+	// skip is the number of user frames of the backtrace to skip when
+	// reporting the failure site. See SkipCallers.
+	skip int
 
 	msg              string
 	value            interface{}
-	valueAnnotations []string // extra attributes of the value
-	extra            string   // printed at the end of the error message
+	valueAnnotations []string      // extra attributes of the value
+	extra            string        // printed at the end of the error message
+	extraFunc        func() string // if set, computes extra lazily
+	nested           []string      // msgs of the nested matchers, innermost first
 }
 
 // Status returns the status code of the match result.
 func (r Result) Status() Status { return r.status }
 
+// Message returns the description of the matcher that produced the result, or
+// the error message if the status is DomainError.
+func (r Result) Message() string { return r.msg }
+
+// Value returns the value under test.
+func (r Result) Value() interface{} { return r.value }
+
+// Annotations returns the extra attributes of the value reported by the
+// matchers, innermost first. For example, ElementsAre reports the element that
+// doesn't match.
+func (r Result) Annotations() []string {
+	return append([]string(nil), r.valueAnnotations...)
+}
+
+// Backtrace returns the stack backtrace when the matcher failed, one frame per
+// line, without the frames of the testutil packages and the Go runtime. It
+// returns "" if the status is Match.
+func (r Result) Backtrace() string { return formatBacktrace(r.backtrace, r.skip) }
+
+// SkipCallers returns a copy of the result that is reported as if the failure
+// happened n user frames further up the stack. Wrapper libraries use it so that
+// the report points at the caller of the wrapper, rather than at the wrapper.
+func (r Result) SkipCallers(n int) Result {
+	if n < 0 {
+		panic("h.SkipCallers: n < 0")
+	}
+	r.skip += n
+	return r
+}
+
 func (r Result) String() string {
 	if r.status == Match {
 		return ""
 	}
 	buf := bytes.NewBuffer(nil)
-	buf.WriteString(fmt.Sprintf("Failure:\n%s\n", r.backtrace))
+	if site, callee, ok := failureSite(r.backtrace, r.skip); ok {
+		buf.WriteString(describeSite(site, callee, r.value))
+	} else {
+		buf.WriteString("Failure:\n")
+	}
 	buf.WriteString(fmt.Sprintf("Actual:   %s", describe(r.value)))
 	for i := len(r.valueAnnotations) - 1; i >= 0; i-- {
 		if i < len(r.valueAnnotations)-1 {
@@ -80,9 +120,21 @@ func (r Result) String() string {
 	} else {
 		buf.WriteString(fmt.Sprintf("Error:    %s\n", r.msg))
 	}
-	if r.extra != "" {
+	extra := r.extra
+	if r.extraFunc != nil {
+		extra = r.extraFunc()
+	}
+	if extra != "" {
 		buf.WriteByte('\n')
-		buf.WriteString(r.extra)
+		buf.WriteString(extra)
+	}
+	// The failure site is already shown at the top. Show the full backtrace
+	// only if the failure happened in a helper function.
+	if frames := userFrames(r.backtrace, r.skip); len(frames) > 1 {
+		buf.WriteString("\nBacktrace:\n")
+		for _, f := range frames {
+			buf.WriteString(fmt.Sprintf("\t%s:%d: %s\n", f.File, f.Line, f.Function))
+		}
 	}
 	return buf.String()
 }
@@ -99,6 +151,55 @@ type Matcher struct {
 	Match func(val interface{}) Result
 	// isEqeual is set only for EQ(). For prettypretting the results.
 	isEqual bool
+	// using rebuilds the matcher with a comparator registry. It is nil if the
+	// matcher doesn't compare values with comparators.
+	using func(reg *ComparatorRegistry) *Matcher
+	// probe checks if a value matches without building a Result, which
+	// captures a backtrace on mismatch. It is nil if the matcher has no such
+	// shortcut.
+	probe func(val interface{}) Status
+}
+
+// matches checks if got matches m, like m.Match. It uses the probe of m, if
+// any, in which case only the status of the result is set, unless it is a
+// DomainError. Matchers that try many values, such as UnorderedElementsAre,
+// use it so that a mismatch doesn't cost a backtrace.
+func (m *Matcher) matches(got interface{}) Result {
+	if m.probe != nil {
+		if s := m.probe(got); s != DomainError {
+			return Result{status: s}
+		}
+	}
+	return m.Match(got)
+}
+
+// probeStatus returns the status of a probe that checked a condition, and
+// failed with err if it is not nil.
+func probeStatus(ok bool, err error) Status {
+	switch {
+	case err != nil:
+		return DomainError
+	case ok:
+		return Match
+	}
+	return Mismatch
+}
+
+// Using returns a copy of the matcher that looks up comparators in reg before
+// the global registry. It applies to EQ, EQWith, NEQ, LT, LE, GT, GE,
+// InRange, and Not of those; it panics for the other matchers.
+//
+// Example:
+//   reg := h.NewComparatorRegistry()
+//   reg.Register(func(a, b *url.URL) (int, error) {
+//     return strings.Compare(a.String(), b.String()), nil
+//   })
+//   assert.That(t, got, h.EQ(want).Using(reg))
+func (m *Matcher) Using(reg *ComparatorRegistry) *Matcher {
+	if m.using == nil {
+		panic(fmt.Sprintf("h.Using: matcher \"%s\" doesn't use comparators", m.Msg))
+	}
+	return m.using(reg)
 }
 
 func describe(v interface{}) string {
@@ -116,18 +217,19 @@ func describeVerbose(v interface{}) string {
 	return fmt.Sprintf("%s(type: %v)", describe(v), vType)
 }
 
-// Function backtrace generates the current stack backtrace w/o internal frames.
-func backtrace() string {
-	pcs := make([]uintptr, 20)
-	n := runtime.Callers(0, pcs)
-	frames := runtime.CallersFrames(pcs[1:n])
+// Function backtrace captures the current stack backtrace w/o internal frames.
+func backtrace() []uintptr {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	return pcs[:n]
+}
+
+// formatBacktrace formats a backtrace captured by backtrace, without the
+// frames of the testutil packages and the Go runtime.
+func formatBacktrace(pcs []uintptr, skip int) string {
 	buf := bytes.NewBuffer(nil)
-	for {
-		frame, ok := frames.Next()
-		if !ok {
-			break
-		}
-		buf.WriteString(fmt.Sprintf("%s:%d: %s\n", frame.File, frame.Line, frame.Function))
+	for _, f := range userFrames(pcs, skip) {
+		buf.WriteString(fmt.Sprintf("%s:%d: %s\n", f.File, f.Line, f.Function))
 	}
 	return buf.String()
 }
@@ -147,8 +249,21 @@ func NewResult(matched bool, got interface{}, msg string) Result {
 	return r
 }
 
-func (r Result) wrap(got interface{}, m *Matcher, annot string) Result {
+// Wrap returns a copy of r, attributed to matcher m and the value got. annot
+// is appended to the annotations of the value. A matcher that delegates to a
+// nested matcher uses it to report a mismatch of the nested matcher without
+// losing the annotations of the nested result.
+//
+// Example:
+//   r := nested.Match(len(got))
+//   if r.Status() == h.Mismatch {
+//     return r.Wrap(got, m, fmt.Sprintf("whose length %d doesn't match", len(got)))
+//   }
+func (r Result) Wrap(got interface{}, m *Matcher, annot string) Result {
 	n := r
+	if r.msg != m.Msg {
+		n.nested = append(append([]string(nil), r.nested...), r.msg)
+	}
 	n.msg = m.Msg
 	n.value = got
 	n.valueAnnotations = append(n.valueAnnotations, annot)
@@ -300,11 +415,27 @@ func Not(val interface{}) *Matcher {
 			r.status = Match
 		default:
 			r.status = Mismatch
+			r.backtrace = backtrace()
 		}
 		r.msg = nm.Msg
 		return r
 
 	}
+	if m.using != nil {
+		nm.using = func(reg *ComparatorRegistry) *Matcher { return Not(m.using(reg)) }
+	}
+	if m.probe != nil {
+		nm.probe = func(got interface{}) Status {
+			switch s := m.probe(got); s {
+			case Match:
+				return Mismatch
+			case Mismatch:
+				return Match
+			default:
+				return s
+			}
+		}
+	}
 	return nm
 }
 
@@ -317,27 +448,11 @@ func Not(val interface{}) *Matcher {
 //
 // - For other data types, the two values x and y are equal if
 //   reflect.DeepEqual(x, y)
-func EQ(want interface{}) *Matcher {
-	wantStr := describe(want)
-	m := &Matcher{
-		isEqual: true,
-		Msg:     wantStr,
-		NotMsg:  "is != " + wantStr,
-	}
-	m.Match = func(got interface{}) Result {
-		c, err := compare(got, want)
-		if err != nil {
-			return NewErrorf(got, m.Msg+": "+err.Error())
-		}
-		r := NewResult(c == cEQ, got, m.Msg)
-		gotStr := describe(got)
-		if r.status != Match && len(wantStr) >= 40 && len(gotStr) >= 40 {
-			r.extra = diffStrings(got, want)
-		}
-		return r
-	}
-	return m
-}
+//
+// On mismatch of structs, maps, slices, or arrays, the failure message lists
+// the path of every differing element, e.g., ".Foo.Bar[3]: got 5, want 6".
+// Multi-line strings are shown as a line diff.
+func EQ(want interface{}) *Matcher { return EQWith(want) }
 
 // NEQ is a shorthand for Not(EQ(want))
 func NEQ(want interface{}) *Matcher { return Not(EQ(want)) }
@@ -347,28 +462,56 @@ func totalOrderPredicate(
 	notMsg string,
 	want interface{},
 	cond func(c compareResult) bool) *Matcher {
+	return totalOrderPredicateWith(msg, notMsg, want, cond, nil)
+}
+
+// totalOrderPredicateWith is totalOrderPredicate that looks up comparators in
+// o.registry.
+func totalOrderPredicateWith(
+	msg string,
+	notMsg string,
+	want interface{},
+	cond func(c compareResult) bool,
+	o *eqOptions) *Matcher {
 	m := &Matcher{
 		Msg:    msg,
 		NotMsg: notMsg,
 	}
 	m.Match = func(got interface{}) Result {
-		c, err := compare(got, want)
-		if err != nil {
-			return NewErrorf(got, msg+": "+err.Error())
+		if c, ok := compareNumbers(o, got, want); ok && c == cNEQ {
+			// NaN is not ordered with respect to any number.
+			r := NewResult(false, got, m.Msg)
+			if isNaN(got) {
+				r.valueAnnotations = append(r.valueAnnotations, "which is NaN")
+			}
+			return r
 		}
-		if c == cNEQ {
-			return NewErrorf(got, msg+": %s and %s are not comparable", describeVerbose(got), describeVerbose(want))
+		c, err := compareOrdered(o, got, want)
+		if err != nil {
+			return NewErrorf(got, "%s: %v", msg, err)
 		}
 		if cond(c) {
 			return NewResult(true, got, m.Msg)
 		}
 		return NewResult(false, got, m.Msg)
 	}
+	m.probe = func(got interface{}) Status {
+		if c, ok := compareNumbers(o, got, want); ok && c == cNEQ {
+			return Mismatch
+		}
+		c, err := compareOrdered(o, got, want)
+		return probeStatus(err == nil && cond(c), err)
+	}
+	m.using = func(reg *ComparatorRegistry) *Matcher {
+		return totalOrderPredicateWith(msg, notMsg, want, cond, newEQOptions([]EQOption{WithComparators(reg)}))
+	}
 	return m
 }
 
 // LT checks if got < want. Got and want must be a numeric (int*, uint*, float*)
-// or a string type.
+// or a string type. Numbers of different types, such as int, float64 and
+// time.Duration, are compared by value. NaN is not ordered with respect to any
+// number, so LT, LE, GT and GE never match it.
 func LT(want interface{}) *Matcher {
 	return totalOrderPredicate(
 		fmt.Sprintf("is < %s", describe(want)),
@@ -480,7 +623,7 @@ func Each(w interface{}) *Matcher {
 	m.Match = func(got interface{}) Result {
 		gotV := reflect.ValueOf(got)
 		if err := indexable(gotV); err != nil {
-			return NewErrorf(got, m.Msg+": "+err.Error())
+			return NewErrorf(got, "%s: %v", m.Msg, err)
 		}
 		n := gotV.Len()
 		for i := 0; i < n; i++ {
@@ -490,7 +633,7 @@ func Each(w interface{}) *Matcher {
 				return r
 			}
 			if r.status == Mismatch {
-				return r.wrap(got, m, fmt.Sprintf("whose element #%d doesn't match", i))
+				return r.Wrap(got, m, fmt.Sprintf("whose element #%d doesn't match", i))
 			}
 		}
 		return NewResult(true, got, m.Msg)
@@ -520,7 +663,7 @@ func Contains(w interface{}) *Matcher {
 	m.Match = func(got interface{}) Result {
 		gotV := reflect.ValueOf(got)
 		if err := indexable(gotV); err != nil {
-			return NewErrorf(got, m.Msg+": "+err.Error())
+			return NewErrorf(got, "%s: %v", m.Msg, err)
 		}
 		n := gotV.Len()
 		for i := 0; i < n; i++ {
@@ -538,6 +681,63 @@ func Contains(w interface{}) *Matcher {
 	return m
 }
 
+// hasLen checks if the value has a length, i.e., if it is an array, slice,
+// string, map, or channel.
+func hasLen(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice, reflect.String, reflect.Map, reflect.Chan:
+		return nil
+	}
+	return fmt.Errorf("%v must be an array, slice, string, map, or channel", describeVerbose(v.Interface()))
+}
+
+// Len checks if the length of the target value matches the given value or
+// matcher. The target must be an array, slice, string, map, or channel. For a
+// channel, the length is the number of buffered elements.
+//
+// Example:
+//   assert.That(t, []int{10, 12}, h.Len(2))
+//   assert.That(t, map[int]int{1: 2}, h.Len(h.LT(2)))
+func Len(w interface{}) *Matcher {
+	want := toMatcher(w)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("has length that %s", phrasify(want)),
+		NotMsg: fmt.Sprintf("has length that %s", want.NotMsg),
+	}
+	m.Match = func(got interface{}) Result {
+		gotV := reflect.ValueOf(got)
+		if err := hasLen(gotV); err != nil {
+			return NewErrorf(got, "%s: %v", m.Msg, err)
+		}
+		n := gotV.Len()
+		switch r := want.Match(n); r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(got, m, fmt.Sprintf("whose length %d doesn't match", n))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// IsEmpty checks if the target value has length zero. The target must be an
+// array, slice, string, map, or channel.
+func IsEmpty() *Matcher {
+	m := &Matcher{
+		Msg:    "is empty",
+		NotMsg: "is not empty",
+	}
+	m.Match = func(got interface{}) Result {
+		gotV := reflect.ValueOf(got)
+		if err := hasLen(gotV); err != nil {
+			return NewErrorf(got, "%s: %v", m.Msg, err)
+		}
+		return NewResult(gotV.Len() == 0, got, m.Msg)
+	}
+	return m
+}
+
 // ElementsAre checks if a sequence matches the given conditions, in order.
 //
 // Example:
@@ -591,13 +791,13 @@ func elementsAreImpl(label string, wants []*Matcher) *Matcher {
 	m.Match = func(got interface{}) Result {
 		gotV := reflect.ValueOf(got)
 		if err := indexable(gotV); err != nil {
-			return NewErrorf(got, m.Msg+": "+err.Error())
+			return NewErrorf(got, "%s: %v", m.Msg, err)
 		}
 		n := gotV.Len()
 		if n != len(wants) {
 			return NewErrorf(got, "%s: length mismatch (%d != %d), got %s, want %s",
 				label, n, len(wants),
-				describe(gotV), strings.Join(msgs, ", "))
+				describe(got), strings.Join(msgs, ", "))
 		}
 		for i := 0; i < n; i++ {
 			elem := gotV.Index(i).Interface()
@@ -606,7 +806,7 @@ func elementsAreImpl(label string, wants []*Matcher) *Matcher {
 				return r
 			}
 			if r.status == Mismatch {
-				return r.wrap(got, m, fmt.Sprintf("whose element #%d doesn't match", i))
+				return r.Wrap(got, m, fmt.Sprintf("whose element #%d doesn't match", i))
 			}
 		}
 		return NewResult(true, got, m.Msg)
@@ -629,7 +829,7 @@ func WhenSorted(m *Matcher) *Matcher {
 	ws.Match = func(got interface{}) Result {
 		gotV := reflect.ValueOf(got)
 		if err := indexable(gotV); err != nil {
-			return NewErrorf(got, m.Msg+": "+err.Error())
+			return NewErrorf(got, "%s: %v", m.Msg, err)
 		}
 		n := gotV.Len()
 		sorted := make([]interface{}, n)
@@ -655,7 +855,7 @@ func WhenSorted(m *Matcher) *Matcher {
 			return c == cLT
 		})
 		if sortErr != nil {
-			return NewErrorf(got, m.Msg+": "+sortErr.Error())
+			return NewErrorf(got, "%s: %v", m.Msg, sortErr)
 		}
 		r := m.Match(sorted)
 		if r.status == Mismatch {
@@ -666,55 +866,6 @@ func WhenSorted(m *Matcher) *Matcher {
 	return ws
 }
 
-// permuter produces permutations of integers {0,1,2,...,n-1}
-//
-// p := newPermuter(3) // produces all permutations of {0,1,2}
-// for p.scan() { // six permutations will be produced.
-//   fmt.Print(p.value())
-// }
-type permuter struct {
-	idxs          []int
-	iter, maxIter int
-}
-
-func newPermuter(n int) *permuter {
-	p := &permuter{idxs: make([]int, n), maxIter: 1}
-	for i := 2; i <= n; i++ {
-		p.maxIter *= i
-	}
-	return p
-}
-
-// nextRec produces the k'th permutation of the given slice.
-//
-// REQUIRES: 0 <= k < factorial(len(s))
-func (p *permuter) permuteRec(s []int, k int) {
-	if len(s) <= 1 {
-		return
-	}
-	m := k % len(s)
-	s[0], s[m] = s[m], s[0]
-	p.permuteRec(s[1:], k/len(s))
-}
-
-// value returns the current permutation.
-//
-// REQUIRES: last scan returned true.
-func (p *permuter) value() []int { return p.idxs }
-
-// scan produces the next permutations. Returns false on EOD.
-func (p *permuter) scan() bool {
-	if p.iter >= p.maxIter {
-		return false
-	}
-	for i := range p.idxs {
-		p.idxs[i] = i
-	}
-	p.permuteRec(p.idxs, p.iter)
-	p.iter++
-	return true
-}
-
 // UnorderedElementsAre checks if the target sequence matches some permutation
 // of the given values.
 func UnorderedElementsAre(w ...interface{}) *Matcher {
@@ -722,7 +873,7 @@ func UnorderedElementsAre(w ...interface{}) *Matcher {
 	for i := range w {
 		wants[i] = toMatcher(w[i])
 	}
-	return unorderedElementsAreImpl("UnorderedElementsAreArray", wants)
+	return unorderedElementsAreImpl(wants, "element")
 }
 
 // UnorderedElementsAreArray checks if the target sequence matches some
@@ -730,15 +881,58 @@ func UnorderedElementsAre(w ...interface{}) *Matcher {
 // array, string, ...).
 func UnorderedElementsAreArray(w interface{}) *Matcher {
 	const label = "UnorderedElementsAreArray"
-	return unorderedElementsAreImpl(label, toMatcherArray(label, w))
+	return unorderedElementsAreImpl(toMatcherArray(label, w), "element")
 }
 
-// UnorderedElementsAre checks if the target sequence matches some permutation
-// of the given values. {
-func unorderedElementsAreImpl(label string, wants []*Matcher) *Matcher {
-	if len(wants) > 8 {
-		panic(fmt.Sprintf("%s: too many args, %d (max: 8)", label, len(wants)))
+// IsSupersetOf checks if the target sequence contains a distinct element for
+// every one of the given values or matchers. The sequence may contain other
+// elements too.
+//
+// Example:
+//   assert.That(t, []int{10, 11, 12}, h.IsSupersetOf(12, h.LT(11)))
+func IsSupersetOf(w ...interface{}) *Matcher {
+	wants := make([]*Matcher, len(w))
+	msgs := make([]string, len(w))
+	for i := range w {
+		wants[i] = toMatcher(w[i])
+		msgs[i] = wants[i].Msg
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is a superset of [%s]", strings.Join(msgs, ", ")),
+		NotMsg: fmt.Sprintf("is not a superset of [%s]", strings.Join(msgs, ", ")),
+	}
+	m.Match = func(got interface{}) Result {
+		return unorderedMatch(got, m, wants, true, false, "element")
+	}
+	return m
+}
+
+// IsSubsetOf checks if every element of the target sequence matches a distinct
+// one of the given values or matchers. Some of the given values may remain
+// unmatched.
+//
+// Example:
+//   assert.That(t, []int{10, 12}, h.IsSubsetOf(10, 11, 12))
+func IsSubsetOf(w ...interface{}) *Matcher {
+	wants := make([]*Matcher, len(w))
+	msgs := make([]string, len(w))
+	for i := range w {
+		wants[i] = toMatcher(w[i])
+		msgs[i] = wants[i].Msg
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is a subset of [%s]", strings.Join(msgs, ", ")),
+		NotMsg: fmt.Sprintf("is not a subset of [%s]", strings.Join(msgs, ", ")),
+	}
+	m.Match = func(got interface{}) Result {
+		return unorderedMatch(got, m, wants, false, true, "element")
 	}
+	return m
+}
+
+// unorderedElementsAreImpl implements UnorderedElementsAre{,Array}. noun
+// names the elements in the description of a mismatch.
+func unorderedElementsAreImpl(wants []*Matcher, noun string) *Matcher {
 	msgs := make([]string, len(wants))
 	for i := range wants {
 		msgs[i] = wants[i].Msg
@@ -748,38 +942,63 @@ func unorderedElementsAreImpl(label string, wants []*Matcher) *Matcher {
 		NotMsg: fmt.Sprintf("do not match any permutation of [%s]", strings.Join(msgs, ", ")),
 	}
 	m.Match = func(got interface{}) Result {
-		gotV := reflect.ValueOf(got)
-		if err := indexable(gotV); err != nil {
-			return NewErrorf(got, m.Msg+": "+err.Error())
-		}
-		n := gotV.Len()
-		if n != len(wants) {
-			return NewErrorf(got, "%s: length mismatch (%d != %d), got %s, want %s",
-				label, n, len(wants),
-				describe(gotV), strings.Join(msgs, ", "))
+		return unorderedMatch(got, m, wants, true, true, noun)
+	}
+	return m
+}
+
+// unorderedMatch pairs the elements of the sequence "got" with "wants" so that
+// each element is paired with at most one matcher that it matches, and vice
+// versa. It finds a maximum pairing by bipartite matching. If allWants (or
+// allElems) is true, the match fails unless every matcher (element) is paired.
+// On failure, the unpaired matchers and elements are listed in the result,
+// where the elements are called noun, e.g., "element" or "key".
+func unorderedMatch(got interface{}, m *Matcher, wants []*Matcher, allWants, allElems bool, noun string) Result {
+	gotV := reflect.ValueOf(got)
+	if err := indexable(gotV); err != nil {
+		return NewErrorf(got, "%s: %v", m.Msg, err)
+	}
+	n := gotV.Len()
+	elems := make([]interface{}, n)
+	for j := range elems {
+		elems[j] = gotV.Index(j).Interface()
+	}
+	adj := make([][]int, len(wants))
+	for i, want := range wants {
+		for j, elem := range elems {
+			r := want.matches(elem)
+			if r.status == DomainError {
+				return r
+			}
+			if r.status == Match {
+				adj[i] = append(adj[i], j)
+			}
 		}
+	}
+	matchWant, matchElem := maxBipartiteMatching(adj, n)
 
-		permuter := newPermuter(n)
-		for permuter.scan() {
-			perm := permuter.value()
-			ok := true
-			for i, j := range perm {
-				r := wants[i].Match(gotV.Index(j).Interface())
-				if r.status == DomainError {
-					return r
-				}
-				if r.status == Mismatch {
-					ok = false
-					break
-				}
+	buf := bytes.NewBuffer(nil)
+	if allWants {
+		for i, j := range matchWant {
+			if j < 0 {
+				buf.WriteString(fmt.Sprintf("No %s matches #%d: %s\n", noun, i, wants[i].Msg))
 			}
-			if ok {
-				return NewResult(true, got, m.Msg)
+		}
+	}
+	if allElems {
+		for j, i := range matchElem {
+			if i < 0 {
+				buf.WriteString(fmt.Sprintf("%s #%d matches nothing: %s\n",
+					strings.ToUpper(noun[:1])+noun[1:], j, describe(elems[j])))
 			}
 		}
-		return NewResult(false, got, m.Msg)
 	}
-	return m
+	if buf.Len() == 0 {
+		return NewResult(true, got, m.Msg)
+	}
+	r := NewResult(false, got, m.Msg)
+	r.extra = buf.String()
+	return r
 }
 
 // AllOf checks if the target value matches all the submatchers.
@@ -874,6 +1093,8 @@ func Zero() *Matcher {
 }
 
 // FloatNear checks if the value is in range [want - maxDelta, want + maxDelta].
+// The target value can be of any integer or float type. See ComplexNear for
+// complex numbers, and FloatRelNear for a relative tolerance.
 //
 // Example:
 //   expect.That(t, 100.001, h.FloatNear(100, 0.1))
@@ -886,14 +1107,9 @@ func FloatNear(want, maxDelta float64) *Matcher {
 		NotMsg: fmt.Sprintf("float is not near %f within delta %f", want, maxDelta),
 	}
 	m.Match = func(got interface{}) Result {
-		var v float64
-		switch got := got.(type) {
-		case float64:
-			v = got
-		case float32:
-			v = float64(got)
-		default:
-			return NewErrorf(got, "expect float")
+		v, r := floatOf("FloatNear", got)
+		if r != nil {
+			return *r
 		}
 		if v >= want-maxDelta && v <= want+maxDelta {
 			return NewResult(true, got, m.Msg)
@@ -903,15 +1119,34 @@ func FloatNear(want, maxDelta float64) *Matcher {
 	return m
 }
 
-var (
-	comparatorMu = sync.Mutex{}
-	comparators  = map[reflect.Type]reflect.Value{}
-)
+// ComparatorRegistry is a set of comparators, keyed by the type they compare.
+// The package-global registry is modified by RegisterComparator. Tests that
+// run in parallel and need different comparators for the same type should
+// instead create their own registries, and attach them to matchers with
+// Matcher.Using. A ComparatorRegistry is safe for concurrent use.
+type ComparatorRegistry struct {
+	mu          sync.RWMutex
+	comparators map[reflect.Type]reflect.Value
+}
+
+// NewComparatorRegistry creates an empty registry.
+func NewComparatorRegistry() *ComparatorRegistry {
+	return &ComparatorRegistry{comparators: map[reflect.Type]reflect.Value{}}
+}
+
+// globalComparators is the registry modified by RegisterComparator. It is
+// consulted by every matcher.
+var globalComparators = NewComparatorRegistry()
+
+// builtinComparators maps types to the comparators that apply when no
+// comparator is registered for them. They can be overridden, but not
+// unregistered.
+var builtinComparators = map[reflect.Type]reflect.Value{}
 
 // Given a function of form func(a,b T) (int, error) this function returns T.
 func comparatorArgType(callback interface{}) reflect.Type {
 	typ := reflect.TypeOf(callback)
-	if typ.Kind() != reflect.Func {
+	if typ == nil || typ.Kind() != reflect.Func {
 		panic(fmt.Sprintf("h.RegisterComparator: %+v is not a function", callback))
 	}
 	errorInterface := reflect.TypeOf((*error)(nil)).Elem()
@@ -926,6 +1161,42 @@ func comparatorArgType(callback interface{}) reflect.Type {
 	return typ.In(0)
 }
 
+// Register registers a comparator function in the registry. The callback has
+// the same form as in RegisterComparator. It replaces any comparator
+// previously registered for the same type.
+func (reg *ComparatorRegistry) Register(callback interface{}) {
+	reg.swap(comparatorArgType(callback), reflect.ValueOf(callback))
+}
+
+// Unregister unregisters the comparator for the argument type of callback. It
+// panics if no comparator is registered for the type.
+func (reg *ComparatorRegistry) Unregister(callback interface{}) {
+	if _, ok := reg.swap(comparatorArgType(callback), reflect.Value{}); !ok {
+		panic(fmt.Sprintf("h.UnregisterComparator: function %+v not registered", callback))
+	}
+}
+
+// swap replaces the comparator for typ with fn, and returns the old
+// comparator, if any. If fn is the zero Value, the comparator is removed.
+func (reg *ComparatorRegistry) swap(typ reflect.Type, fn reflect.Value) (old reflect.Value, ok bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	old, ok = reg.comparators[typ]
+	if fn.IsValid() {
+		reg.comparators[typ] = fn
+	} else {
+		delete(reg.comparators, typ)
+	}
+	return old, ok
+}
+
+func (reg *ComparatorRegistry) find(typ reflect.Type) (reflect.Value, bool) {
+	reg.mu.RLock()
+	v, ok := reg.comparators[typ]
+	reg.mu.RUnlock()
+	return v, ok
+}
+
 // RegisterComparator registers a comparator function for a user-defined type.
 // The callback should have signature
 //
@@ -939,29 +1210,44 @@ func comparatorArgType(callback interface{}) reflect.Type {
 // value if a>b. It should return a non-nil error if a and b are not comparable,
 // or on any other error. The callback may define its own meanings of ">", "==",
 // and "<", but they must define a total ordering over T.
+//
+// The comparator is registered in a package-global registry, which affects
+// every test in the process. See ComparatorRegistry for an alternative.
 func RegisterComparator(callback interface{}) {
-	argType := comparatorArgType(callback)
-	comparatorMu.Lock()
-	comparators[argType] = reflect.ValueOf(callback)
-	comparatorMu.Unlock()
+	globalComparators.Register(callback)
 }
 
 // UnregisterComparator unregisters the callback registered in
 // RegisteredComparator.  It panics if the callback was not registered.
 func UnregisterComparator(callback interface{}) {
-	argType := comparatorArgType(callback)
-	comparatorMu.Lock()
-	if _, ok := comparators[argType]; !ok {
-		panic(fmt.Sprintf("h.UnregisterComparator: function %+v not regiseterd", callback))
-	}
-	delete(comparators, argType)
-	comparatorMu.Unlock()
+	globalComparators.Unregister(callback)
 }
 
-func findComparator(typ reflect.Type) (reflect.Value, bool) {
-	comparatorMu.Lock()
-	v, ok := comparators[typ]
-	comparatorMu.Unlock()
+// RegisterComparatorForTest is like RegisterComparator, but the registration
+// is undone when the test finishes, restoring the comparator previously
+// registered for the same type, if any. t is usually a *testing.T.
+//
+// The comparator is still visible to the other tests that run in parallel.
+// Use a ComparatorRegistry with Matcher.Using to isolate parallel tests.
+func RegisterComparatorForTest(t interface{ Cleanup(func()) }, callback interface{}) {
+	typ := comparatorArgType(callback)
+	old, _ := globalComparators.swap(typ, reflect.ValueOf(callback))
+	t.Cleanup(func() { globalComparators.swap(typ, old) })
+}
+
+// findComparator finds the comparator for typ. The registry in o, if any, takes
+// precedence over the global registry, which takes precedence over the
+// built-in comparators. o may be nil.
+func findComparator(o *eqOptions, typ reflect.Type) (reflect.Value, bool) {
+	if o != nil && o.registry != nil {
+		if v, ok := o.registry.find(typ); ok {
+			return v, ok
+		}
+	}
+	if v, ok := globalComparators.find(typ); ok {
+		return v, ok
+	}
+	v, ok := builtinComparators[typ]
 	return v, ok
 }
 
@@ -990,8 +1276,18 @@ type visit struct {
 }
 
 func compare(x, y interface{}) (compareResult, error) {
-	visited := map[visit]bool{}
-	return compareRec(reflect.ValueOf(x), reflect.ValueOf(y), visited)
+	return newComparer(nil).compare(reflect.ValueOf(x), reflect.ValueOf(y))
+}
+
+// comparer holds the state of one invocation of compare.
+type comparer struct {
+	// opts customizes the equality. It is nil for the default semantics.
+	opts    *eqOptions
+	visited map[visit]bool
+}
+
+func newComparer(opts *eqOptions) *comparer {
+	return &comparer{opts: opts, visited: map[visit]bool{}}
 }
 
 func isIntKind(typ reflect.Type) bool {
@@ -1014,9 +1310,27 @@ func isComplexKind(typ reflect.Type) bool {
 	return k == reflect.Complex64 || k == reflect.Complex128
 }
 
-func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, error) {
-	if !xv.IsValid() {
-		if yv.IsValid() {
+func (cmp *comparer) compare(xv, yv reflect.Value) (compareResult, error) {
+	if !xv.IsValid() || !yv.IsValid() {
+		if xv.IsValid() != yv.IsValid() {
+			return cNEQ, nil
+		}
+		return cEQ, nil
+	}
+	if xt, yt, ok, err := cmp.transform(xv, yv); ok || err != nil {
+		if err != nil {
+			return cNEQ, err
+		}
+		return cmp.compareTransformed(xt, yt)
+	}
+	return cmp.compareTransformed(xv, yv)
+}
+
+// compareTransformed implements compare after the transformers have been
+// applied to xv and yv.
+func (cmp *comparer) compareTransformed(xv, yv reflect.Value) (compareResult, error) {
+	if !xv.IsValid() || !yv.IsValid() {
+		if xv.IsValid() != yv.IsValid() {
 			return cNEQ, nil
 		}
 		return cEQ, nil
@@ -1031,7 +1345,7 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 		return cEQ, fmt.Errorf("%+v(type:%v) and %+v(type:%v) are not comparable", xv, xType, yv, yType)
 	}
 
-	comparator, ok := findComparator(xType)
+	comparator, ok := findComparator(cmp.opts, xType)
 	if ok {
 		retval := comparator.Call([]reflect.Value{xv, yv})
 		if !retval[1].IsNil() { // error?
@@ -1062,10 +1376,10 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 		// Short circuit if references are already seen.
 		typ := xv.Type()
 		v := visit{xaddr, yaddr, typ}
-		if visited[v] {
+		if cmp.visited[v] {
 			return cEQ, nil
 		}
-		visited[v] = true
+		cmp.visited[v] = true
 	}
 
 	switch {
@@ -1095,15 +1409,15 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 		return cNEQ, nil
 	case isFloatKind(xType):
 		xi, yi := xv.Float(), yv.Float()
+		if cmp.floatsEqual(xi, yi) {
+			return cEQ, nil
+		}
 		if xi < yi {
 			return cLT, nil
 		}
 		if xi > yi {
 			return cGT, nil
 		}
-		if xi == yi {
-			return cEQ, nil
-		}
 		return cNEQ, nil // xi or yi is NaN
 	case xType.Kind() == reflect.String:
 		xi, yi := xv.String(), yv.String()
@@ -1130,8 +1444,11 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 		if xv.Len() != yv.Len() {
 			return cNEQ, nil
 		}
+		if cmp.opts != nil && cmp.opts.ignoreOrder {
+			return cmp.compareUnordered(xv, yv)
+		}
 		for i := 0; i < xv.Len(); i++ {
-			c, err := compareRec(xv.Index(i), yv.Index(i), visited)
+			c, err := cmp.compare(xv.Index(i), yv.Index(i))
 			if err != nil {
 				return c, err
 			}
@@ -1147,12 +1464,12 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 			}
 			return cNEQ, nil
 		}
-		return compareRec(xv.Elem(), yv.Elem(), visited)
+		return cmp.compare(xv.Elem(), yv.Elem())
 	case xType.Kind() == reflect.Ptr:
 		if xv.Pointer() == yv.Pointer() {
 			return cEQ, nil
 		}
-		return compareRec(xv.Elem(), yv.Elem(), visited)
+		return cmp.compare(xv.Elem(), yv.Elem())
 	case xType.Kind() == reflect.Chan:
 		if xv.Pointer() == yv.Pointer() {
 			return cEQ, nil
@@ -1160,7 +1477,10 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 		return cNEQ, nil
 	case xType.Kind() == reflect.Struct:
 		for i, n := 0, xv.NumField(); i < n; i++ {
-			r, err := compareRec(xv.Field(i), yv.Field(i), visited)
+			if cmp.opts != nil && cmp.opts.ignoreField(xType, i) {
+				continue
+			}
+			r, err := cmp.compare(xv.Field(i), yv.Field(i))
 			if r != cEQ || err != nil {
 				return cNEQ, err
 			}
@@ -1168,6 +1488,9 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 		return cEQ, nil
 	case xType.Kind() == reflect.Map:
 		if xv.IsNil() != yv.IsNil() {
+			if cmp.opts != nil && cmp.opts.equateEmpty && xv.Len() == 0 && yv.Len() == 0 {
+				return cEQ, nil
+			}
 			return cNEQ, nil
 		}
 		if xv.Len() != yv.Len() {
@@ -1182,7 +1505,7 @@ func compareRec(xv, yv reflect.Value, visited map[visit]bool) (compareResult, er
 			if !val1.IsValid() || !val2.IsValid() {
 				return cNEQ, nil
 			}
-			r, err := compareRec(xv.MapIndex(k), yv.MapIndex(k), visited)
+			r, err := cmp.compare(xv.MapIndex(k), yv.MapIndex(k))
 			if r != cEQ || err != nil {
 				return cNEQ, err
 			}
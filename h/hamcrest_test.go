@@ -99,7 +99,7 @@ func TestEach(t *testing.T) {
 	expect.Regexp(t, h.Each(h.HasSubstr("a")).Match([]string{"ab", "cd"}).String(),
 		"(?s)whose element #1 doesn't match.*every element in sequence has substr")
 	expect.EQ(t, h.Each(h.HasSubstr("a")).Match([]string{"ab", "ac"}).Status(), h.Match)
-	expect.Regexp(t, h.Each(h.LT(5.0)).Match([]int{12, 34}),
+	expect.Regexp(t, h.Each(h.LT("x")).Match([]int{12, 34}),
 		`Error:.*are not comparable`)
 }
 
@@ -137,11 +137,54 @@ func TestUnorderedElementsAre(t *testing.T) {
 		"Expected: match some permutation of")
 	expect.Regexp(t, h.UnorderedElementsAre(10, 12).Match(map[int]int{10: 110, 12: 112}),
 		`Error:.*must be a slice, array, or string`)
+	expect.HasSubstr(t, h.UnorderedElementsAre(10, 11, h.GT(9)).Match([]int{12, 10, 9}),
+		"No element matches #1: (int)11\nElement #2 matches nothing: (int)9\n")
+	// A greedy assignment would pair GT(9) with 10 and leave 10 unmatched.
+	expect.That(t, []int{11, 10}, h.UnorderedElementsAre(h.GT(9), 10))
+
+	// Sequences of different lengths are mismatches that list the unpaired
+	// elements and matchers.
+	expect.That(t, h.UnorderedElementsAre(10, 11).Match([]int{11, 12, 10}),
+		resultIs(h.Mismatch, `(?s)Expected: match some permutation of.*\nElement #1 matches nothing: \(int\)12\n$`))
+	expect.That(t, h.UnorderedElementsAre(10, 11, 12).Match([]int{11, 10}),
+		resultIs(h.Mismatch, `(?s)Expected: match some permutation of.*\nNo element matches #2: \(int\)12\n$`))
+	expect.That(t, []int{10}, h.Not(h.UnorderedElementsAre(10, 11)))
+	expect.That(t, []int{10, 11, 12}, h.Not(h.UnorderedElementsAreArray([]int{10, 11})))
+}
+
+func TestUnorderedElementsAreLarge(t *testing.T) {
+	const n = 1000
+	var got, want []int
+	for i := 0; i < n; i++ {
+		got = append(got, n-i-1)
+		want = append(want, i)
+	}
+	expect.That(t, got, h.UnorderedElementsAreArray(want))
+	got[0] = -1
+	expect.That(t, h.UnorderedElementsAreArray(want).Match(got),
+		resultIs(h.Mismatch, `(?s)No element matches #999: \(int\)999\nElement #0 matches nothing: \(int\)-1\n`))
+}
+
+func TestIsSupersetOf(t *testing.T) {
+	expect.That(t, []int{10, 11, 12}, h.IsSupersetOf(12, h.LT(11)))
+	expect.That(t, []int{10, 11, 12}, h.IsSupersetOf())
+	expect.That(t, []int{10}, h.Not(h.IsSupersetOf(10, 10)))
+	expect.That(t, h.IsSupersetOf(10, 13).Match([]int{10, 11, 12}),
+		resultIs(h.Mismatch, `(?s)Expected: is a superset of \[\(int\)10, \(int\)13\].*No element matches #1: \(int\)13\n$`))
+	expect.That(t, h.IsSupersetOf(10).Match(10), resultIs(h.DomainError, "must be a slice, array, or string"))
+}
+
+func TestIsSubsetOf(t *testing.T) {
+	expect.That(t, []int{10, 12}, h.IsSubsetOf(10, 11, 12))
+	expect.That(t, []int{}, h.IsSubsetOf(10))
+	expect.That(t, []int{10, 10}, h.Not(h.IsSubsetOf(10, 11)))
+	expect.That(t, h.IsSubsetOf(10, 11).Match([]int{10, 10}),
+		resultIs(h.Mismatch, `(?s)Expected: is a subset of .*Element #1 matches nothing: \(int\)10\n$`))
 }
 
 func TestWhenSorted(t *testing.T) {
 	expect.That(t, h.WhenSorted(h.ElementsAre(10, 11)).Match([]int{11, 10, 9}),
-		resultIs(h.DomainError, "length mismatch"))
+		resultIs(h.DomainError, `length mismatch \(3 != 2\), got \(\[\]interface \{\}\)\[\(int\)9 \(int\)10 \(int\)11\], want`))
 	expect.Regexp(t, h.WhenSorted(h.ElementsAre(10, 11)).Match([]int{11, 9}),
 		`(?s)Actual:.*\[\(int\)9 \(int\)11\] whose element #0.*when sorted, elements are \[\(int\)10 \(int\)11\].*`)
 	expect.EQ(t, h.WhenSorted(h.ElementsAre(10, 11)).Match([]int{11, 10}).Status(), h.Match)
@@ -204,12 +247,8 @@ func TestLargeValuesShowsDiffs(t *testing.T) {
 		got = append(got, s{i * 2, i*2 + 1})
 		want = append(want, s{i * 2, i*2 + 1})
 	}
-	expect.HasSubstr(t, h.EQ(want).Match(got),
-		`(h_test.s) {
--   x: (int) 1040,
--   y: (int) 1041
--  },
--  (h_test.s) {`)
+	expect.HasSubstr(t, h.EQ(want).Match(got), "  [20]: got extra element {x:1040 y:1041}\n")
+	expect.That(t, h.EQ(want).Match(got), h.Not(h.Regexp(`\[21\]: `)))
 }
 
 func TestLongStringsShowsDiffs(t *testing.T) {
@@ -513,3 +552,46 @@ func ExamplePanics() {
 	// Output:
 	// hello
 }
+
+func TestComparatorRegistry(t *testing.T) {
+	type version struct{ major, minor int }
+	byMajor := h.NewComparatorRegistry()
+	byMajor.Register(func(x, y version) (int, error) { return x.major - y.major, nil })
+	type pkg struct {
+		Name string
+		V    version
+	}
+	t.Run("major", func(t *testing.T) {
+		t.Parallel()
+		expect.That(t, version{1, 2}, h.EQ(version{1, 3}).Using(byMajor))
+		expect.That(t, pkg{"a", version{1, 2}}, h.EQWith(pkg{"a", version{1, 3}}, h.WithComparators(byMajor)))
+		expect.That(t, version{1, 2}, h.Not(h.LT(version{1, 3})).Using(byMajor))
+		expect.That(t, version{1, 2}, h.NEQ(version{2, 2}).Using(byMajor))
+		expect.That(t, version{1, 2}, h.InRange(version{1, 0}, version{1, 9}, true).Using(byMajor))
+	})
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+		expect.That(t, version{1, 2}, h.NEQ(version{1, 3}))
+		expect.That(t, h.LT(version{1, 3}).Match(version{1, 2}), resultIs(h.DomainError, "not comparable"))
+	})
+	expect.That(t, func() { h.HasSubstr("x").Using(byMajor) }, h.Panics(h.HasSubstr("doesn't use comparators")))
+	expect.That(t, func() { byMajor.Unregister(func(x, y string) (int, error) { return 0, nil }) },
+		h.Panics(h.HasSubstr("not registered")))
+}
+
+func TestRegisterComparatorForTest(t *testing.T) {
+	type celsius struct{ deg float64 }
+	t.Run("scoped", func(t *testing.T) {
+		h.RegisterComparatorForTest(t, func(x, y celsius) (int, error) {
+			switch {
+			case x.deg < y.deg-0.5:
+				return -1, nil
+			case x.deg > y.deg+0.5:
+				return 1, nil
+			}
+			return 0, nil
+		})
+		expect.EQ(t, celsius{20.1}, celsius{20.3})
+	})
+	expect.NEQ(t, celsius{20.1}, celsius{20.3})
+}
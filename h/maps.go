@@ -0,0 +1,263 @@
+package h
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+func checkMap(label string, got interface{}) (reflect.Value, *Result) {
+	gotV := reflect.ValueOf(got)
+	if gotV.Kind() != reflect.Map {
+		r := NewErrorf(got, "%s: %v must be a map", label, describeVerbose(got))
+		return gotV, &r
+	}
+	return gotV, nil
+}
+
+// sortedMapKeys returns the keys of a map, sorted by their descriptions so that
+// the matcher output is deterministic.
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return describe(keys[i].Interface()) < describe(keys[j].Interface()) })
+	return keys
+}
+
+// HasKey checks if a map contains a key that matches the given value or
+// matcher.
+//
+// Example:
+//   assert.That(t, map[string]int{"a": 1}, h.HasKey("a"))
+//   assert.That(t, map[string]int{"a": 1}, h.HasKey(h.HasPrefix("a")))
+func HasKey(key interface{}) *Matcher {
+	wantKey := toMatcher(key)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("map has key that %s", phrasify(wantKey)),
+		NotMsg: fmt.Sprintf("map does not have key that %s", phrasify(wantKey)),
+	}
+	m.Match = func(got interface{}) Result {
+		gotV, r := checkMap("HasKey", got)
+		if r != nil {
+			return *r
+		}
+		for _, k := range gotV.MapKeys() {
+			r := wantKey.Match(k.Interface())
+			if r.status == DomainError {
+				return r
+			}
+			if r.status == Match {
+				return NewResult(true, got, m.Msg)
+			}
+		}
+		return NewResult(false, got, m.Msg)
+	}
+	return m
+}
+
+// HasKeys checks if a map contains keys that match every one of the given
+// values or matchers.
+//
+// Example:
+//   assert.That(t, map[string]int{"a": 1, "b": 2, "c": 3}, h.HasKeys("a", "b"))
+func HasKeys(keys ...interface{}) *Matcher {
+	matchers := make([]*Matcher, len(keys))
+	msgs := make([]string, len(keys))
+	for i, k := range keys {
+		matchers[i] = HasKey(k)
+		msgs[i] = phrasify(toMatcher(k))
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("map has keys that [%s]", strings.Join(msgs, ", ")),
+		NotMsg: fmt.Sprintf("map does not have keys that [%s]", strings.Join(msgs, ", ")),
+	}
+	m.Match = func(got interface{}) Result {
+		for i, km := range matchers {
+			switch r := km.Match(got); r.status {
+			case DomainError:
+				return r
+			case Mismatch:
+				return r.Wrap(got, m, fmt.Sprintf("which has no key that %s", msgs[i]))
+			}
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// mapProjection creates a matcher that applies "want" to the keys or values of
+// a map, extracted by "project".
+func mapProjection(label, what string, want *Matcher, project func(v reflect.Value) []interface{}) *Matcher {
+	m := &Matcher{
+		Msg:    fmt.Sprintf("map %s %s", what, want.Msg),
+		NotMsg: fmt.Sprintf("map %s %s", what, want.NotMsg),
+	}
+	m.Match = func(got interface{}) Result {
+		gotV, r := checkMap(label, got)
+		if r != nil {
+			return *r
+		}
+		elems := project(gotV)
+		r2 := want.Match(elems)
+		switch r2.status {
+		case DomainError:
+			return r2
+		case Mismatch:
+			return r2.Wrap(got, m, fmt.Sprintf("whose %s %s don't match", what, describe(elems)))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// MapKeysAre checks if the keys of a map match the given values or matchers, in
+// any order. It is a shorthand for applying UnorderedElementsAre to the keys.
+// On mismatch, the extra keys and the values that no key matches are listed.
+//
+// Example:
+//   assert.That(t, map[string]int{"a": 1, "b": 2}, h.MapKeysAre("b", "a"))
+func MapKeysAre(w ...interface{}) *Matcher {
+	const label = "MapKeysAre"
+	return mapProjection(label, "keys", unorderedElementsAreImpl(toMatcherArray(label, w), "key"), func(v reflect.Value) []interface{} {
+		keys := sortedMapKeys(v)
+		elems := make([]interface{}, len(keys))
+		for i, k := range keys {
+			elems[i] = k.Interface()
+		}
+		return elems
+	})
+}
+
+// MapValuesAre checks if the values of a map match the given values or
+// matchers, in any order. It is a shorthand for applying UnorderedElementsAre
+// to the values. On mismatch, the extra values and the matchers that no value
+// matches are listed.
+//
+// Example:
+//   assert.That(t, map[string]int{"a": 1, "b": 2}, h.MapValuesAre(h.LT(2), 2))
+func MapValuesAre(w ...interface{}) *Matcher {
+	const label = "MapValuesAre"
+	return mapProjection(label, "values", unorderedElementsAreImpl(toMatcherArray(label, w), "value"), func(v reflect.Value) []interface{} {
+		keys := sortedMapKeys(v)
+		elems := make([]interface{}, len(keys))
+		for i, k := range keys {
+			elems[i] = v.MapIndex(k).Interface()
+		}
+		return elems
+	})
+}
+
+// MapEQ checks if a map has exactly the keys of "want", and that the value of
+// every key matches the corresponding value in "want". "want" must be a map.
+// Its values can be immediate values or Matchers; its keys are compared with
+// the keys of the target value as in EQ. On mismatch, the missing keys, the
+// extra keys and the mismatched values are reported separately.
+//
+// Example:
+//   assert.That(t, map[string]int{"a": 1, "b": 2},
+//       h.MapEQ(map[string]interface{}{"a": 1, "b": h.GT(1)}))
+func MapEQ(want interface{}) *Matcher {
+	wantV := reflect.ValueOf(want)
+	if wantV.Kind() != reflect.Map {
+		panic(fmt.Sprintf("h.MapEQ: arg must be a map, but got %s", describeVerbose(want)))
+	}
+	wantKeys := sortedMapKeys(wantV)
+	wantVals := make([]*Matcher, len(wantKeys))
+	msgs := make([]string, len(wantKeys))
+	for i, k := range wantKeys {
+		wantVals[i] = toMatcher(wantV.MapIndex(k).Interface())
+		msgs[i] = fmt.Sprintf("%s: %s", describe(k.Interface()), phrasify(wantVals[i]))
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("map entries are {%s}", strings.Join(msgs, ", ")),
+		NotMsg: fmt.Sprintf("map entries are not {%s}", strings.Join(msgs, ", ")),
+	}
+	m.Match = func(got interface{}) Result {
+		gotV, r := checkMap("MapEQ", got)
+		if r != nil {
+			return *r
+		}
+		gotKeys := sortedMapKeys(gotV)
+		// Keys of the same type are looked up directly. The others, and the keys
+		// that may be equal without being identical, are compared one by one.
+		sameType := gotV.Type().Key() == wantV.Type().Key()
+		exact := sameType && exactKeys(wantV.Type().Key())
+		seen := map[interface{}]bool{}
+		var missing, mismatched []string
+		for i, wk := range wantKeys {
+			gk, found := wk, sameType && gotV.MapIndex(wk).IsValid()
+			for k := 0; k < len(gotKeys) && !found && !exact; k++ {
+				c, err := compare(gotKeys[k].Interface(), wk.Interface())
+				if err != nil {
+					return NewErrorf(got, "MapEQ: %v", err)
+				}
+				gk, found = gotKeys[k], c == cEQ
+			}
+			if !found {
+				missing = append(missing, describe(wk.Interface()))
+				continue
+			}
+			seen[gk.Interface()] = true
+			val := gotV.MapIndex(gk).Interface()
+			switch r := wantVals[i].Match(val); r.status {
+			case DomainError:
+				return r
+			case Mismatch:
+				mismatched = append(mismatched, fmt.Sprintf("[%s]: got %s, want %s",
+					describe(wk.Interface()), describe(val), phrasify(wantVals[i])))
+			}
+		}
+		var extra []string
+		for _, gk := range gotKeys {
+			if !seen[gk.Interface()] {
+				extra = append(extra, describe(gk.Interface()))
+			}
+		}
+		if len(missing) == 0 && len(extra) == 0 && len(mismatched) == 0 {
+			return NewResult(true, got, m.Msg)
+		}
+		r2 := NewResult(false, got, m.Msg)
+		buf := bytes.NewBuffer(nil)
+		if len(missing) > 0 {
+			buf.WriteString(fmt.Sprintf("Missing keys: %s\n", strings.Join(missing, ", ")))
+		}
+		if len(extra) > 0 {
+			buf.WriteString(fmt.Sprintf("Extra keys: %s\n", strings.Join(extra, ", ")))
+		}
+		if len(mismatched) > 0 {
+			buf.WriteString("Mismatched values:\n")
+			for _, s := range mismatched {
+				buf.WriteString("  " + s + "\n")
+			}
+		}
+		r2.extra = buf.String()
+		return r2
+	}
+	return m
+}
+
+// exactKeys checks if map keys of type typ are equal only when they are
+// identical, so that looking a key up in a map finds every key that compare
+// considers equal.
+func exactKeys(typ reflect.Type) bool {
+	if _, ok := findComparator(nil, typ); ok {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	case reflect.Array:
+		return exactKeys(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if !exactKeys(typ.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
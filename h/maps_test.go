@@ -0,0 +1,91 @@
+package h_test
+
+import (
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestHasKey(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2}
+	expect.That(t, m, h.HasKey("a"))
+	expect.That(t, m, h.HasKey(h.HasPrefix("b")))
+	expect.That(t, m, h.Not(h.HasKey("c")))
+	expect.That(t, h.HasKey("c").Match(m), resultIs(h.Mismatch, `Expected: map has key that is \(string\)c`))
+	expect.That(t, h.HasKey("c").Match([]int{1}), resultIs(h.DomainError, "must be a map"))
+	expect.That(t, h.HasKey(1).Match(m), resultIs(h.DomainError, "not comparable"))
+
+	expect.That(t, m, h.HasKeys("a", "b"))
+	expect.That(t, m, h.Not(h.HasKeys("a", "c")))
+	expect.That(t, h.HasKeys("a", "c").Match(m), resultIs(h.Mismatch, `which has no key that is \(string\)c`))
+}
+
+func TestMapKeysAndValuesAre(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2}
+	expect.That(t, m, h.MapKeysAre("b", "a"))
+	expect.That(t, m, h.MapValuesAre(h.LT(2), 2))
+	expect.That(t, map[string]int{}, h.MapKeysAre())
+	expect.That(t, h.MapKeysAre("a", "c").Match(m),
+		resultIs(h.Mismatch, `whose keys \(\[\]interface \{\}\)\[\(string\)a \(string\)b\] don't match\nExpected: map keys match some permutation`))
+	expect.That(t, h.MapKeysAre("a", "c").Match(m),
+		resultIs(h.Mismatch, `\n\nNo key matches #1: \(string\)c\nKey #1 matches nothing: \(string\)b\n$`))
+	expect.That(t, h.MapKeysAre("a").Match(m), resultIs(h.Mismatch, `\n\nKey #1 matches nothing: \(string\)b\n$`))
+	expect.That(t, h.MapKeysAre("a", "b", "c").Match(m), resultIs(h.Mismatch, `\n\nNo key matches #2: \(string\)c\n$`))
+	expect.That(t, m, h.Not(h.MapKeysAre("a")))
+	expect.That(t, h.MapValuesAre(1).Match(m), resultIs(h.Mismatch, `don't match\n.*\n\nValue #1 matches nothing: \(int\)2\n$`))
+}
+
+func TestMapEQ(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	expect.That(t, m, h.MapEQ(map[string]int{"a": 1, "b": 2, "c": 3}))
+	expect.That(t, m, h.MapEQ(map[string]interface{}{"a": 1, "b": h.GT(1), "c": h.Any()}))
+	expect.That(t, map[string]int{}, h.MapEQ(map[string]int{}))
+
+	r := h.MapEQ(map[string]interface{}{"a": 1, "b": h.GT(2), "d": 4, "e": 5}).Match(m)
+	expect.That(t, r, resultIs(h.Mismatch, `Expected: map entries are \{\(string\)a: is \(int\)1, \(string\)b: > \(int\)2, `))
+	expect.HasSubstr(t, r, `Missing keys: (string)d, (string)e
+Extra keys: (string)c
+Mismatched values:
+  [(string)b]: got (int)2, want > (int)2
+`)
+	expect.That(t, h.MapEQ(map[int]int{1: 1}).Match(m), resultIs(h.DomainError, "not comparable"))
+	expect.That(t, func() { h.MapEQ(10) }, h.Panics(h.HasSubstr("must be a map")))
+
+	// Keys of different types, or keys that are equal without being identical,
+	// are compared like EQ does.
+	expect.That(t, map[int64]string{1: "a"}, h.MapEQ(map[int]string{1: "a"}))
+	x, y := 1, 1
+	expect.That(t, map[*int]string{&x: "a"}, h.MapEQ(map[*int]string{&y: "a"}))
+
+	// Large maps are matched by lookup.
+	big := map[int]int{}
+	for i := 0; i < 10000; i++ {
+		big[i] = i
+	}
+	expect.That(t, big, h.MapEQ(big))
+}
+
+func TestLen(t *testing.T) {
+	expect.That(t, []int{1, 2}, h.Len(2))
+	expect.That(t, [3]int{}, h.Len(3))
+	expect.That(t, "abc", h.Len(h.GT(2)))
+	expect.That(t, map[int]int{1: 1}, h.Len(1))
+	ch := make(chan int, 2)
+	ch <- 1
+	expect.That(t, ch, h.Len(1))
+	expect.That(t, h.Len(3).Match([]int{1, 2}),
+		resultIs(h.Mismatch, `whose length 2 doesn't match\nExpected: has length that is \(int\)3`))
+	expect.That(t, h.Len(3).Match(10), resultIs(h.DomainError, "must be an array, slice, string, map, or channel"))
+}
+
+func TestIsEmpty(t *testing.T) {
+	expect.That(t, []int{}, h.IsEmpty())
+	expect.That(t, []int(nil), h.IsEmpty())
+	expect.That(t, "", h.IsEmpty())
+	expect.That(t, map[int]int{}, h.IsEmpty())
+	expect.That(t, make(chan int), h.IsEmpty())
+	expect.That(t, []int{1}, h.Not(h.IsEmpty()))
+	expect.That(t, h.IsEmpty().Match("x"), resultIs(h.Mismatch, "Expected: is empty"))
+	expect.That(t, h.IsEmpty().Match(1), resultIs(h.DomainError, "must be an array"))
+}
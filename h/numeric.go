@@ -0,0 +1,353 @@
+package h
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// isNumericKind checks if typ is an integer or a float type.
+func isNumericKind(typ reflect.Type) bool {
+	return isIntKind(typ) || isUintKind(typ) || isFloatKind(typ)
+}
+
+// compareNumbers compares two numbers of possibly different integer and float
+// types, such as int, float64 and time.Duration. It returns ok=false if x or y
+// is not a number, or if a comparator is registered for either type, in the
+// global registry or in o.registry. It returns cNEQ if x or y is NaN. o may be
+// nil.
+func compareNumbers(o *eqOptions, x, y interface{}) (c compareResult, ok bool) {
+	xv, yv := reflect.ValueOf(x), reflect.ValueOf(y)
+	if !xv.IsValid() || !yv.IsValid() || !isNumericKind(xv.Type()) || !isNumericKind(yv.Type()) {
+		return cNEQ, false
+	}
+	if _, found := findComparator(o, xv.Type()); found {
+		return cNEQ, false
+	}
+	if _, found := findComparator(o, yv.Type()); found {
+		return cNEQ, false
+	}
+	order := func(less, greater bool) compareResult {
+		switch {
+		case less:
+			return cLT
+		case greater:
+			return cGT
+		}
+		return cEQ
+	}
+	xt, yt := xv.Type(), yv.Type()
+	switch {
+	case isFloatKind(xt) || isFloatKind(yt):
+		if isNaN(x) || isNaN(y) {
+			return cNEQ, true
+		}
+		// Converting a large integer to float64 may round it, so the values are
+		// compared exactly.
+		c := toBigFloat(xv).Cmp(toBigFloat(yv))
+		return order(c < 0, c > 0), true
+	case isIntKind(xt) && isIntKind(yt):
+		return order(xv.Int() < yv.Int(), xv.Int() > yv.Int()), true
+	case isUintKind(xt) && isUintKind(yt):
+		return order(xv.Uint() < yv.Uint(), xv.Uint() > yv.Uint()), true
+	case isIntKind(xt): // y is unsigned.
+		if xv.Int() < 0 {
+			return cLT, true
+		}
+		return order(uint64(xv.Int()) < yv.Uint(), uint64(xv.Int()) > yv.Uint()), true
+	default: // x is unsigned, y is signed.
+		if yv.Int() < 0 {
+			return cGT, true
+		}
+		return order(xv.Uint() < uint64(yv.Int()), xv.Uint() > uint64(yv.Int())), true
+	}
+}
+
+// toFloat64 converts a number of any integer or float type to float64.
+func toFloat64(v reflect.Value) float64 {
+	switch {
+	case isIntKind(v.Type()):
+		return float64(v.Int())
+	case isUintKind(v.Type()):
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+// toBigFloat converts a number of any integer or float type, other than NaN,
+// to a big.Float without rounding.
+func toBigFloat(v reflect.Value) *big.Float {
+	switch {
+	case isIntKind(v.Type()):
+		return new(big.Float).SetInt64(v.Int())
+	case isUintKind(v.Type()):
+		return new(big.Float).SetUint64(v.Uint())
+	}
+	return big.NewFloat(v.Float())
+}
+
+// floatOf converts the value under test to float64. It accepts any integer or
+// float type.
+func floatOf(label string, got interface{}) (float64, *Result) {
+	v := reflect.ValueOf(got)
+	if !v.IsValid() || !isNumericKind(v.Type()) {
+		r := NewErrorf(got, "%s: %s must be a number", label, describeVerbose(got))
+		return 0, &r
+	}
+	return toFloat64(v), nil
+}
+
+// compareOrdered compares got and want for the ordering matchers. Numbers of
+// different types are compared by value. o may be nil.
+func compareOrdered(o *eqOptions, got, want interface{}) (compareResult, error) {
+	if c, ok := compareNumbers(o, got, want); ok {
+		return c, nil
+	}
+	c, err := newComparer(o).compare(reflect.ValueOf(got), reflect.ValueOf(want))
+	if err == nil && c == cNEQ {
+		err = fmt.Errorf("%s and %s are not comparable", describeVerbose(got), describeVerbose(want))
+	}
+	return c, err
+}
+
+// isNaN checks if v is a floating-point NaN.
+func isNaN(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.IsValid() && isFloatKind(rv.Type()) && math.IsNaN(rv.Float())
+}
+
+// FloatRelNear checks if the value is near want within a relative tolerance,
+// that is, |got - want| <= relTol * max(|got|, |want|). The target value can be
+// of any integer or float type.
+//
+// Example:
+//   expect.That(t, mean, h.FloatRelNear(1e9, 1e-6))
+func FloatRelNear(want, relTol float64) *Matcher {
+	if relTol < 0 || math.IsNaN(relTol) {
+		panic("h.FloatRelNear: relTol < 0")
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("float is near %g within relative tolerance %g", want, relTol),
+		NotMsg: fmt.Sprintf("float is not near %g within relative tolerance %g", want, relTol),
+	}
+	m.Match = func(got interface{}) Result {
+		v, r := floatOf("FloatRelNear", got)
+		if r != nil {
+			return *r
+		}
+		if v == want {
+			return NewResult(true, got, m.Msg) // Handles infinities.
+		}
+		diff := math.Abs(v - want)
+		return NewResult(diff <= relTol*math.Max(math.Abs(v), math.Abs(want)), got, m.Msg)
+	}
+	return m
+}
+
+// ulpDistance returns the number of representable float64 values between x
+// and y. It returns math.MaxUint64 if x or y is NaN.
+func ulpDistance(x, y float64) uint64 {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.MaxUint64
+	}
+	// Map the floats to integers that are ordered like the floats.
+	toOrdered := func(f float64) int64 {
+		b := int64(math.Float64bits(f))
+		if b < 0 {
+			b = math.MinInt64 - b
+		}
+		return b
+	}
+	a, b := toOrdered(x), toOrdered(y)
+	if a > b {
+		a, b = b, a
+	}
+	return uint64(b) - uint64(a)
+}
+
+// ulpDistance32 is ulpDistance for float32.
+func ulpDistance32(x, y float32) uint64 {
+	if x != x || y != y {
+		return math.MaxUint64
+	}
+	toOrdered := func(f float32) int64 {
+		b := int32(math.Float32bits(f))
+		if b < 0 {
+			b = math.MinInt32 - b
+		}
+		return int64(b)
+	}
+	a, b := toOrdered(x), toOrdered(y)
+	if a > b {
+		a, b = b, a
+	}
+	return uint64(b - a)
+}
+
+// WithinULP checks if the value is a float at most maxULP units in the last
+// place away from want, i.e., there are at most maxULP-1 representable floats
+// between them. A float32 value is compared in float32 precision.
+//
+// Example:
+//   expect.That(t, 0.1+0.2, h.WithinULP(0.3, 1))
+func WithinULP(want float64, maxULP uint64) *Matcher {
+	m := &Matcher{
+		Msg:    fmt.Sprintf("float is within %d ULP of %g", maxULP, want),
+		NotMsg: fmt.Sprintf("float is not within %d ULP of %g", maxULP, want),
+	}
+	m.Match = func(got interface{}) Result {
+		var d uint64
+		switch g := got.(type) {
+		case float64:
+			d = ulpDistance(g, want)
+		case float32:
+			d = ulpDistance32(g, float32(want))
+		default:
+			return NewErrorf(got, "WithinULP: %s must be a float", describeVerbose(got))
+		}
+		r := NewResult(d <= maxULP, got, m.Msg)
+		if r.status != Match && d != math.MaxUint64 {
+			r.valueAnnotations = append(r.valueAnnotations, fmt.Sprintf("which is %d ULP away", d))
+		}
+		return r
+	}
+	return m
+}
+
+// floatPredicate creates a matcher that applies pred to a float32 or float64
+// value.
+func floatPredicate(label, msg, notMsg string, pred func(f float64) bool) *Matcher {
+	m := &Matcher{Msg: msg, NotMsg: notMsg}
+	m.Match = func(got interface{}) Result {
+		v := reflect.ValueOf(got)
+		if !v.IsValid() || !isFloatKind(v.Type()) {
+			return NewErrorf(got, "%s: %s must be a float", label, describeVerbose(got))
+		}
+		return NewResult(pred(v.Float()), got, m.Msg)
+	}
+	return m
+}
+
+// IsNaN checks if the value is a float NaN.
+func IsNaN() *Matcher {
+	return floatPredicate("IsNaN", "is NaN", "is not NaN", math.IsNaN)
+}
+
+// IsInf checks if the value is a float infinity, according to sign. If sign >
+// 0, it checks for positive infinity; if sign < 0, for negative infinity; if
+// sign == 0, for either infinity.
+func IsInf(sign int) *Matcher {
+	desc := "infinity"
+	switch {
+	case sign > 0:
+		desc = "+infinity"
+	case sign < 0:
+		desc = "-infinity"
+	}
+	return floatPredicate("IsInf", "is "+desc, "is not "+desc, func(f float64) bool { return math.IsInf(f, sign) })
+}
+
+// InRange checks if lo <= got <= hi if inclusive is true, or lo < got < hi
+// otherwise. The values must be of types that LT accepts; numbers of different
+// types, such as int, float64 and time.Duration, are compared by value.
+//
+// Example:
+//   expect.That(t, latency, h.InRange(time.Millisecond, 0.5e9, true))
+func InRange(lo, hi interface{}, inclusive bool) *Matcher {
+	return inRange(lo, hi, inclusive, nil)
+}
+
+// inRange is InRange that looks up comparators in o.registry.
+func inRange(lo, hi interface{}, inclusive bool, o *eqOptions) *Matcher {
+	left, right := "(", ")"
+	if inclusive {
+		left, right = "[", "]"
+	}
+	rangeStr := fmt.Sprintf("%s%s, %s%s", left, describe(lo), describe(hi), right)
+	m := &Matcher{
+		Msg:    "is in range " + rangeStr,
+		NotMsg: "is not in range " + rangeStr,
+	}
+	m.Match = func(got interface{}) Result {
+		cl, err := compareOrdered(o, got, lo)
+		if err != nil {
+			return NewErrorf(got, "%s: %v", m.Msg, err)
+		}
+		ch, err := compareOrdered(o, got, hi)
+		if err != nil {
+			return NewErrorf(got, "%s: %v", m.Msg, err)
+		}
+		ok := (cl == cGT || (inclusive && cl == cEQ)) && (ch == cLT || (inclusive && ch == cEQ))
+		return NewResult(ok, got, m.Msg)
+	}
+	m.using = func(reg *ComparatorRegistry) *Matcher {
+		return inRange(lo, hi, inclusive, newEQOptions([]EQOption{WithComparators(reg)}))
+	}
+	return m
+}
+
+// SliceFloatNear checks if the value is a slice or an array of floats of the
+// same length as want, and that every element is within maxDelta of the
+// corresponding element of want.
+//
+// Example:
+//   expect.That(t, []float64{0.1 + 0.2, 1}, h.SliceFloatNear([]float64{0.3, 1}, 1e-9))
+func SliceFloatNear(want []float64, maxDelta float64) *Matcher {
+	if maxDelta < 0 {
+		panic("h.SliceFloatNear: maxDelta < 0")
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("floats are near %v within delta %g", want, maxDelta),
+		NotMsg: fmt.Sprintf("floats are not near %v within delta %g", want, maxDelta),
+	}
+	m.Match = func(got interface{}) Result {
+		v := reflect.ValueOf(got)
+		if err := indexable(v); err != nil {
+			return NewErrorf(got, "SliceFloatNear: %v", err)
+		}
+		if !isFloatKind(v.Type().Elem()) {
+			return NewErrorf(got, "SliceFloatNear: %s must hold floats", describeVerbose(got))
+		}
+		if v.Len() != len(want) {
+			return NewErrorf(got, "SliceFloatNear: length mismatch (%d != %d)", v.Len(), len(want))
+		}
+		for i, w := range want {
+			e := v.Index(i).Float()
+			if !(math.Abs(e-w) <= maxDelta) {
+				r := NewResult(false, got, m.Msg)
+				r.valueAnnotations = append(r.valueAnnotations,
+					fmt.Sprintf("whose element #%d %g is not within %g of %g", i, e, maxDelta, w))
+				return r
+			}
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// ComplexNear checks if the value is a complex number whose distance from want
+// in the complex plane is at most maxDelta. It is the complex counterpart of
+// FloatNear, which can't accept complex numbers without changing the type of
+// its want argument, and breaking its callers.
+//
+// Example:
+//   expect.That(t, cmplx.Exp(1i*math.Pi), h.ComplexNear(-1, 1e-12))
+func ComplexNear(want complex128, maxDelta float64) *Matcher {
+	if maxDelta < 0 {
+		panic("h.ComplexNear: maxDelta < 0")
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("complex is near %v within delta %g", want, maxDelta),
+		NotMsg: fmt.Sprintf("complex is not near %v within delta %g", want, maxDelta),
+	}
+	m.Match = func(got interface{}) Result {
+		v := reflect.ValueOf(got)
+		if !v.IsValid() || !isComplexKind(v.Type()) {
+			return NewErrorf(got, "ComplexNear: %s must be a complex number", describeVerbose(got))
+		}
+		c := v.Complex() - want
+		return NewResult(math.Hypot(real(c), imag(c)) <= maxDelta, got, m.Msg)
+	}
+	return m
+}
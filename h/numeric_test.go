@@ -0,0 +1,108 @@
+package h_test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+	"time"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestCrossTypeOrdering(t *testing.T) {
+	expect.That(t, 3, h.LT(3.5))
+	expect.That(t, 3.5, h.GT(3))
+	expect.That(t, uint8(3), h.LE(int64(3)))
+	expect.That(t, -1, h.LT(uint(0)))
+	expect.That(t, uint(0), h.GT(-1))
+	expect.That(t, 2*time.Second, h.GT(1e9))
+	expect.That(t, 2*time.Second, h.LT(int64(3e9)))
+	expect.That(t, h.LT(3).Match(4.5), resultIs(h.Mismatch, `Actual:   \(float64\)4.5\nExpected: is < \(int\)3`))
+	expect.That(t, h.LT("a").Match(1), resultIs(h.DomainError, "not comparable"))
+
+	// Integers that float64 can't represent are compared exactly.
+	expect.That(t, float64(1<<53), h.LT(int64(1<<53+1)))
+	expect.That(t, int64(1<<53+1), h.GT(float64(1<<53)))
+	expect.That(t, uint64(math.MaxUint64), h.LT(float64(1<<64)))
+	expect.That(t, int64(math.MinInt64), h.AllOf(h.LE(float64(-1<<63)), h.GE(float64(-1<<63))))
+	expect.That(t, int64(3), h.GT(2.5))
+	expect.That(t, int64(-3), h.LT(-2.5))
+	expect.That(t, int64(math.MaxInt64), h.LT(math.Inf(1)))
+}
+
+func TestOrderingNaN(t *testing.T) {
+	nan := math.NaN()
+	for _, m := range []*h.Matcher{h.LT(1.0), h.LE(1.0), h.GT(1.0), h.GE(1.0)} {
+		expect.That(t, nan, h.Not(m))
+		expect.That(t, 1.0, h.Not(h.LT(nan)))
+	}
+	expect.That(t, h.LT(1.0).Match(nan), resultIs(h.Mismatch, "NaN which is NaN"))
+}
+
+func TestFloatRelNear(t *testing.T) {
+	expect.That(t, 1e9+1, h.FloatRelNear(1e9, 1e-6))
+	expect.That(t, 1e9+1e4, h.Not(h.FloatRelNear(1e9, 1e-6)))
+	expect.That(t, 100, h.FloatRelNear(101, 0.01))
+	expect.That(t, math.Inf(1), h.FloatRelNear(math.Inf(1), 0))
+	expect.That(t, 0.0, h.FloatRelNear(0, 0))
+	expect.That(t, math.NaN(), h.Not(h.FloatRelNear(0, 1)))
+	expect.That(t, h.FloatRelNear(1, 0.1).Match("x"), resultIs(h.DomainError, "must be a number"))
+	expect.That(t, func() { h.FloatRelNear(1, -1) }, h.Panics(h.HasSubstr("relTol < 0")))
+}
+
+func TestWithinULP(t *testing.T) {
+	x, y := 0.1, 0.2
+	expect.That(t, x+y, h.WithinULP(0.3, 1))
+	expect.That(t, x+y, h.Not(h.WithinULP(0.3, 0)))
+	expect.That(t, math.Nextafter(-0.0, 1), h.WithinULP(0, 1))
+	expect.That(t, math.Nextafter(0, -1), h.WithinULP(math.Nextafter(0, 1), 2))
+	expect.That(t, float32(x)+float32(y), h.WithinULP(0.3, 1))
+	expect.That(t, math.NaN(), h.Not(h.WithinULP(math.NaN(), 100)))
+	expect.That(t, h.WithinULP(1, 2).Match(math.Nextafter(math.Nextafter(math.Nextafter(1, 2), 2), 2)),
+		resultIs(h.Mismatch, "which is 3 ULP away\nExpected: float is within 2 ULP of 1"))
+	expect.That(t, h.WithinULP(1, 1).Match(1), resultIs(h.DomainError, "must be a float"))
+}
+
+func TestIsNaNAndIsInf(t *testing.T) {
+	expect.That(t, math.NaN(), h.IsNaN())
+	expect.That(t, float32(math.NaN()), h.IsNaN())
+	expect.That(t, 1.0, h.Not(h.IsNaN()))
+	expect.That(t, math.Inf(1), h.IsInf(0))
+	expect.That(t, math.Inf(-1), h.IsInf(-1))
+	expect.That(t, math.Inf(-1), h.Not(h.IsInf(1)))
+	expect.That(t, h.IsInf(1).Match(1.0), resultIs(h.Mismatch, `Expected: is \+infinity`))
+	expect.That(t, h.IsNaN().Match(1), resultIs(h.DomainError, "must be a float"))
+}
+
+func TestInRange(t *testing.T) {
+	expect.That(t, 5, h.InRange(1, 10, false))
+	expect.That(t, 10, h.InRange(1, 10, true))
+	expect.That(t, 10, h.Not(h.InRange(1, 10, false)))
+	expect.That(t, 2.5, h.InRange(2, 3, false))
+	expect.That(t, 20*time.Millisecond, h.InRange(time.Millisecond, 0.5e9, true))
+	expect.That(t, "b", h.InRange("a", "c", false))
+	expect.That(t, math.NaN(), h.Not(h.InRange(0, 1, true)))
+	expect.That(t, h.InRange(1, 2, true).Match(3), resultIs(h.Mismatch, `Expected: is in range \[\(int\)1, \(int\)2\]`))
+	expect.That(t, h.InRange(1, 2, false).Match(3), resultIs(h.Mismatch, `Expected: is in range \(\(int\)1, \(int\)2\)`))
+	expect.That(t, h.InRange(1, 2, false).Match("x"), resultIs(h.DomainError, "not comparable"))
+}
+
+func TestSliceFloatNear(t *testing.T) {
+	x, y := 0.1, 0.2
+	expect.That(t, []float64{x + y, 1}, h.SliceFloatNear([]float64{0.3, 1}, 1e-9))
+	expect.That(t, [2]float32{1, 2}, h.SliceFloatNear([]float64{1, 2}, 0))
+	expect.That(t, h.SliceFloatNear([]float64{1, 2}, 0.1).Match([]float64{1, 2.5}),
+		resultIs(h.Mismatch, "whose element #1 2.5 is not within 0.1 of 2\nExpected: floats are near \\[1 2\\] within delta 0.1"))
+	expect.That(t, h.SliceFloatNear([]float64{1}, 0.1).Match([]float64{1, 2}), resultIs(h.DomainError, "length mismatch"))
+	expect.That(t, h.SliceFloatNear([]float64{1}, 0.1).Match([]int{1}), resultIs(h.DomainError, "must hold floats"))
+	expect.That(t, []float64{math.NaN()}, h.Not(h.SliceFloatNear([]float64{0}, 1)))
+}
+
+func TestComplexNear(t *testing.T) {
+	expect.That(t, cmplx.Exp(1i*math.Pi), h.ComplexNear(-1, 1e-12))
+	expect.That(t, complex64(1+1i), h.ComplexNear(1, 1))
+	expect.That(t, 2+2i, h.Not(h.ComplexNear(1+1i, 1)))
+	expect.That(t, h.ComplexNear(1, 1).Match(1.0), resultIs(h.DomainError, "must be a complex number"))
+	expect.That(t, 10, h.FloatNear(10.2, 0.5))
+}
@@ -0,0 +1,245 @@
+package h
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// EQOption customizes the equality used by EQWith.
+type EQOption func(o *eqOptions)
+
+type eqOptions struct {
+	descs []string // descriptions of the options, for matcher messages.
+
+	// ignoreFields is the set of ignored struct fields. A key is either a
+	// field name, or "Type.Field".
+	ignoreFields     map[string]bool
+	ignoreUnexported bool
+	equateEmpty      bool
+	floatEpsilon     float64 // < 0 if floats are compared exactly.
+	ignoreOrder      bool
+	transformers     map[reflect.Type]reflect.Value
+	// registry, if not nil, is consulted for comparators before the global
+	// registry.
+	registry *ComparatorRegistry
+}
+
+func newEQOptions(opts []EQOption) *eqOptions {
+	o := &eqOptions{
+		ignoreFields: map[string]bool{},
+		floatEpsilon: -1,
+		transformers: map[reflect.Type]reflect.Value{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ignoreField checks if the i'th field of struct type typ should be skipped.
+func (o *eqOptions) ignoreField(typ reflect.Type, i int) bool {
+	f := typ.Field(i)
+	if o.ignoreUnexported && f.PkgPath != "" {
+		return true
+	}
+	return o.ignoreFields[f.Name] || o.ignoreFields[typ.Name()+"."+f.Name]
+}
+
+// IgnoreFields makes EQWith skip struct fields of the given names. A name is
+// either a plain field name, which is ignored in every struct, or of form
+// "Type.Field", which is ignored only in structs of the given type name.
+//
+// Example:
+//   assert.That(t, got, h.EQWith(want, h.IgnoreFields("CreatedAt", "Record.ID")))
+func IgnoreFields(names ...string) EQOption {
+	return func(o *eqOptions) {
+		for _, name := range names {
+			o.ignoreFields[name] = true
+		}
+		o.descs = append(o.descs, fmt.Sprintf("ignoring fields %s", strings.Join(names, ", ")))
+	}
+}
+
+// IgnoreUnexported makes EQWith skip unexported struct fields.
+func IgnoreUnexported() EQOption {
+	return func(o *eqOptions) {
+		o.ignoreUnexported = true
+		o.descs = append(o.descs, "ignoring unexported fields")
+	}
+}
+
+// EquateEmpty makes EQWith treat a nil map equal to an empty map. Nil and
+// empty slices are always treated as equal.
+func EquateEmpty() EQOption {
+	return func(o *eqOptions) {
+		o.equateEmpty = true
+		o.descs = append(o.descs, "nil equals empty")
+	}
+}
+
+// FloatEpsilon makes EQWith treat two floats x and y as equal if |x-y| <=
+// epsilon.
+func FloatEpsilon(epsilon float64) EQOption {
+	if epsilon < 0 {
+		panic("h.FloatEpsilon: epsilon < 0")
+	}
+	return func(o *eqOptions) {
+		o.floatEpsilon = epsilon
+		o.descs = append(o.descs, fmt.Sprintf("floats within %g", epsilon))
+	}
+}
+
+// IgnoreOrder makes EQWith treat two slices or arrays as equal if they contain
+// the same elements, regardless of order.
+func IgnoreOrder() EQOption {
+	return func(o *eqOptions) {
+		o.ignoreOrder = true
+		o.descs = append(o.descs, "ignoring order")
+	}
+}
+
+// WithComparators makes EQWith look up comparators in reg before the global
+// registry. EQWith(want, h.WithComparators(reg)) is the same as
+// EQWith(want).Using(reg).
+func WithComparators(reg *ComparatorRegistry) EQOption {
+	return func(o *eqOptions) {
+		o.registry = reg
+	}
+}
+
+// Transform makes EQWith compare values of type T after converting them with
+// fn. The callback should have signature
+//
+//     func(v T) U
+//
+// where T and U are arbitrary types.  It applies to values of type T wherever
+// they appear, including inside structs, slices, maps, and pointers. For
+// example, the following compares timestamps with one-second granularity:
+//
+//   h.Transform(func(t time.Time) time.Time { return t.Truncate(time.Second) })
+func Transform(fn interface{}) EQOption {
+	fnV := reflect.ValueOf(fn)
+	if fnV.Kind() != reflect.Func || fnV.Type().NumIn() != 1 || fnV.Type().NumOut() != 1 {
+		panic(fmt.Sprintf("h.Transform: %s must be a function of form func(T) U", describeVerbose(fn)))
+	}
+	return func(o *eqOptions) {
+		argType := fnV.Type().In(0)
+		o.transformers[argType] = fnV
+		o.descs = append(o.descs, fmt.Sprintf("transforming %v with %s", argType, funcName(fnV)))
+	}
+}
+
+// EQWith is like EQ, but the definition of equality can be customized by the
+// options. For example, the following ignores fields that hold timestamps and
+// generated IDs, and compares the floats approximately.
+//
+//   assert.That(t, got, h.EQWith(want,
+//       h.IgnoreFields("CreatedAt", "ID"), h.FloatEpsilon(1e-9)))
+func EQWith(want interface{}, opts ...EQOption) *Matcher {
+	var o *eqOptions
+	wantStr := describe(want)
+	if len(opts) > 0 {
+		o = newEQOptions(opts)
+		if len(o.descs) > 0 {
+			wantStr += " (" + strings.Join(o.descs, "; ") + ")"
+		}
+	}
+	m := &Matcher{
+		isEqual: true,
+		Msg:     wantStr,
+		NotMsg:  "is != " + wantStr,
+	}
+	eq := func(got interface{}) (compareResult, error) {
+		return newComparer(o).compare(addressable(reflect.ValueOf(got)), addressable(reflect.ValueOf(want)))
+	}
+	m.Match = func(got interface{}) Result {
+		c, err := eq(got)
+		if err != nil {
+			return NewErrorf(got, "%s: %v", m.Msg, err)
+		}
+		r := NewResult(c == cEQ, got, m.Msg)
+		if r.status != Match {
+			r.extraFunc = func() string { return diffValuesWith(got, want, o) }
+		}
+		return r
+	}
+	m.probe = func(got interface{}) Status {
+		c, err := eq(got)
+		return probeStatus(c == cEQ, err)
+	}
+	m.using = func(reg *ComparatorRegistry) *Matcher {
+		return EQWith(want, append(opts[:len(opts):len(opts)], WithComparators(reg))...)
+	}
+	return m
+}
+
+// transform applies the transformer registered for the type of xv and yv, if
+// any.
+func (cmp *comparer) transform(xv, yv reflect.Value) (reflect.Value, reflect.Value, bool, error) {
+	if cmp.opts == nil || xv.Type() != yv.Type() {
+		return xv, yv, false, nil
+	}
+	fn, ok := cmp.opts.transformers[xv.Type()]
+	if !ok {
+		return xv, yv, false, nil
+	}
+	xv, yv = readable(xv), readable(yv)
+	if !xv.CanInterface() || !yv.CanInterface() {
+		return xv, yv, false, fmt.Errorf("cannot transform %v reached through an unexported field", xv.Type())
+	}
+	return addressable(fn.Call([]reflect.Value{xv})[0]), addressable(fn.Call([]reflect.Value{yv})[0]), true, nil
+}
+
+// floatsEqual checks if two floats are equal within the epsilon given by
+// FloatEpsilon.
+func (cmp *comparer) floatsEqual(x, y float64) bool {
+	if cmp.opts == nil || cmp.opts.floatEpsilon < 0 {
+		return x == y
+	}
+	return math.Abs(x-y) <= cmp.opts.floatEpsilon
+}
+
+// compareUnordered checks if sequences xv and yv hold the same elements,
+// ignoring order.
+func (cmp *comparer) compareUnordered(xv, yv reflect.Value) (compareResult, error) {
+	if xv.Len() != yv.Len() {
+		return cNEQ, nil
+	}
+	unmatchedX, _, err := cmp.pairElements(xv, yv)
+	if err != nil || len(unmatchedX) > 0 {
+		return cNEQ, err
+	}
+	return cEQ, nil
+}
+
+// pairElements pairs the elements of xv with equal elements of yv, finding as
+// many pairs as possible even when the equality isn't transitive, e.g., with
+// FloatEpsilon. It returns the indexes of elements left unpaired in xv and yv.
+func (cmp *comparer) pairElements(xv, yv reflect.Value) (unmatchedX, unmatchedY []int, err error) {
+	adj := make([][]int, xv.Len())
+	for i := range adj {
+		for j := 0; j < yv.Len(); j++ {
+			c, err := newComparer(cmp.opts).compare(xv.Index(i), yv.Index(j))
+			if err != nil {
+				return nil, nil, err
+			}
+			if c == cEQ {
+				adj[i] = append(adj[i], j)
+			}
+		}
+	}
+	matchL, matchR := maxBipartiteMatching(adj, yv.Len())
+	for i, j := range matchL {
+		if j < 0 {
+			unmatchedX = append(unmatchedX, i)
+		}
+	}
+	for j, i := range matchR {
+		if i < 0 {
+			unmatchedY = append(unmatchedY, j)
+		}
+	}
+	return unmatchedX, unmatchedY, nil
+}
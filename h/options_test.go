@@ -0,0 +1,96 @@
+package h_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+type record struct {
+	ID        string
+	Name      string
+	Score     float64
+	Tags      []string
+	Attrs     map[string]string
+	CreatedAt time.Time
+	internal  int
+}
+
+func TestEQWithIgnoreFields(t *testing.T) {
+	got := record{ID: "a1", Name: "foo", CreatedAt: time.Now()}
+	want := record{ID: "b2", Name: "foo"}
+	expect.That(t, got, h.Not(h.EQ(want)))
+	expect.That(t, got, h.EQWith(want, h.IgnoreFields("ID", "CreatedAt")))
+	expect.That(t, got, h.EQWith(want, h.IgnoreFields("record.ID", "record.CreatedAt")))
+	expect.That(t, got, h.Not(h.EQWith(want, h.IgnoreFields("other.ID", "CreatedAt"))))
+
+	want.Name = "bar"
+	r := h.EQWith(want, h.IgnoreFields("ID", "CreatedAt")).Match(got)
+	expect.That(t, r, resultIs(h.Mismatch, `Expected: .* \(ignoring fields ID, CreatedAt\)`))
+	expect.HasSubstr(t, r, `  .Name: got "foo", want "bar"`)
+	expect.That(t, r.String(), h.Not(h.HasSubstr(".ID:")))
+}
+
+func TestEQWithIgnoreUnexported(t *testing.T) {
+	got := record{Name: "foo", internal: 1}
+	want := record{Name: "foo", internal: 2}
+	expect.That(t, got, h.Not(h.EQ(want)))
+	expect.That(t, got, h.EQWith(want, h.IgnoreUnexported()))
+	expect.HasSubstr(t, h.EQ(want).Match(got), "  .internal: got 1, want 2")
+}
+
+func TestEQWithEquateEmpty(t *testing.T) {
+	got := record{Attrs: map[string]string{}}
+	want := record{}
+	expect.That(t, got, h.Not(h.EQ(want)))
+	expect.That(t, got, h.EQWith(want, h.EquateEmpty()))
+	expect.That(t, record{Attrs: map[string]string{"a": "b"}}, h.Not(h.EQWith(want, h.EquateEmpty())))
+	expect.That(t, record{Tags: []string{}}, h.EQWith(want, h.EquateEmpty()))
+}
+
+func TestEQWithFloatEpsilon(t *testing.T) {
+	expect.That(t, 1.0, h.EQWith(1.05, h.FloatEpsilon(0.1)))
+	expect.That(t, 1.0, h.Not(h.EQWith(1.2, h.FloatEpsilon(0.1))))
+	expect.That(t, record{Score: 0.3}, h.EQWith(record{Score: 0.1 + 0.2}, h.FloatEpsilon(1e-9)))
+	expect.That(t, []float32{1, 2}, h.EQWith([]float32{1.01, 1.99}, h.FloatEpsilon(0.02)))
+	expect.That(t, func() { h.FloatEpsilon(-1) }, h.Panics(h.HasSubstr("epsilon < 0")))
+}
+
+func TestEQWithIgnoreOrder(t *testing.T) {
+	expect.That(t, []int{3, 1, 2}, h.EQWith([]int{1, 2, 3}, h.IgnoreOrder()))
+	expect.That(t, []int{1, 1, 2}, h.Not(h.EQWith([]int{1, 2, 2}, h.IgnoreOrder())))
+	expect.That(t, record{Tags: []string{"b", "a"}}, h.EQWith(record{Tags: []string{"a", "b"}}, h.IgnoreOrder()))
+
+	r := h.EQWith([]int{1, 2, 3}, h.IgnoreOrder()).Match([]int{3, 4, 1})
+	expect.HasSubstr(t, r, "  [1]: got extra element 4\n  [1]: missing element 2\n")
+
+	// With an epsilon, equality isn't transitive: 2 is equal to both 1 and 3,
+	// so it must be paired with 3 for 1 to find its pair.
+	expect.That(t, []float64{2, 1}, h.EQWith([]float64{1, 3}, h.FloatEpsilon(1), h.IgnoreOrder()))
+	r = h.EQWith([]float64{1, 3, 9}, h.FloatEpsilon(1), h.IgnoreOrder()).Match([]float64{2, 1, 5})
+	expect.HasSubstr(t, r, "  [2]: got extra element 5\n  [2]: missing element 9\n")
+}
+
+func TestEQWithTransform(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	truncate := h.Transform(func(t time.Time) time.Time { return t.Truncate(time.Second) })
+	expect.That(t, record{CreatedAt: t0.Add(time.Millisecond)}, h.EQWith(record{CreatedAt: t0}, truncate))
+	expect.That(t, record{CreatedAt: t0.Add(time.Second)}, h.Not(h.EQWith(record{CreatedAt: t0}, truncate)))
+
+	lower := h.Transform(strings.ToLower)
+	expect.That(t, []string{"ABC", "Def"}, h.EQWith([]string{"abc", "def"}, lower))
+	expect.HasSubstr(t, h.EQWith([]string{"abc", "def"}, lower).Match([]string{"ABC", "xyz"}),
+		`  [1]: got "xyz", want "def"`)
+
+	expect.That(t, func() { h.Transform(10) }, h.Panics(h.HasSubstr("must be a function")))
+}
+
+func TestEQWithCombined(t *testing.T) {
+	got := record{ID: "x", Score: 1.00001, Tags: []string{"b", "a"}, internal: 3}
+	want := record{ID: "y", Score: 1, Tags: []string{"a", "b"}}
+	expect.That(t, got, h.EQWith(want,
+		h.IgnoreFields("ID"), h.IgnoreUnexported(), h.FloatEpsilon(1e-3), h.IgnoreOrder()))
+}
@@ -0,0 +1,79 @@
+package h
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// describeProto describes a message in the compact text format.
+func describeProto(msg proto.Message) string {
+	text := prototext.MarshalOptions{}.Format(msg)
+	// The text format randomly inserts extra spaces to discourage byte-wise
+	// comparisons; squash them so that messages are stable.
+	return fmt.Sprintf("%s{%s}", msg.ProtoReflect().Descriptor().FullName(), strings.Join(strings.Fields(text), " "))
+}
+
+// protoTree converts a message to a tree, so that it can be compared with
+// diffTrees.
+func protoTree(msg proto.Message) (interface{}, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(data)
+}
+
+// ProtoEq checks if the value is a protobuf message equal to want, as defined
+// by proto.Equal. Unlike EQ, it ignores the internal state of the generated
+// message structs, such as size caches and unknown-field buffers. The target
+// value must be a proto.Message of the same type as want. On mismatch, the
+// differences are listed by the JSONPath of the fields, using their proto
+// names.
+//
+// Example:
+//   assert.That(t, resp, h.ProtoEq(&pb.Response{Status: pb.Status_OK}))
+func ProtoEq(want proto.Message) *Matcher {
+	if want == nil {
+		panic("h.ProtoEq: want must not be nil")
+	}
+	wantStr := describeProto(want)
+	wantName := want.ProtoReflect().Descriptor().FullName()
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is proto equal to %s", wantStr),
+		NotMsg: fmt.Sprintf("is not proto equal to %s", wantStr),
+	}
+	m.Match = func(got interface{}) Result {
+		gotMsg, ok := got.(proto.Message)
+		if !ok {
+			return NewErrorf(got, "ProtoEq: %s must be a proto.Message", describeVerbose(got))
+		}
+		if name := gotMsg.ProtoReflect().Descriptor().FullName(); name != wantName {
+			return NewErrorf(describeProto(gotMsg), "ProtoEq: got message type %s, want %s", name, wantName)
+		}
+		r := NewResult(proto.Equal(gotMsg, want), describeProto(gotMsg), m.Msg)
+		if r.status != Match {
+			r.extraFunc = func() string {
+				gotTree, err := protoTree(gotMsg)
+				if err != nil {
+					return ""
+				}
+				wantTree, err := protoTree(want)
+				if err != nil {
+					return ""
+				}
+				var lines []string
+				diffTrees("$", gotTree, wantTree, &lines)
+				if len(lines) == 0 {
+					return ""
+				}
+				return formatDiff(lines)
+			}
+		}
+		return r
+	}
+	return m
+}
@@ -0,0 +1,35 @@
+package h_test
+
+import (
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProtoEq(t *testing.T) {
+	newStruct := func(m map[string]interface{}) *structpb.Struct {
+		s, err := structpb.NewStruct(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	want := newStruct(map[string]interface{}{"a": "x", "b": []interface{}{1, 2}})
+	got := newStruct(map[string]interface{}{"a": "x", "b": []interface{}{1, 2}})
+	_ = got.String() // populates internal state that reflect.DeepEqual would see.
+	expect.That(t, got, h.ProtoEq(want))
+	expect.That(t, &durationpb.Duration{Seconds: 1}, h.ProtoEq(&durationpb.Duration{Seconds: 1}))
+
+	r := h.ProtoEq(want).Match(newStruct(map[string]interface{}{"a": "y", "b": []interface{}{1, 3}}))
+	expect.That(t, r, resultIs(h.Mismatch, `Expected: is proto equal to google.protobuf.Struct\{fields:`))
+	expect.HasSubstr(t, r, `Diff (got vs want):
+  $.a: got "y", want "x"
+  $.b[1]: got 3, want 2
+`)
+	expect.That(t, h.ProtoEq(want).Match(&durationpb.Duration{}),
+		resultIs(h.DomainError, "got message type google.protobuf.Duration, want google.protobuf.Struct"))
+	expect.That(t, h.ProtoEq(want).Match(10), resultIs(h.DomainError, "must be a proto.Message"))
+}
@@ -0,0 +1,286 @@
+package h
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Frame is a stack frame in a Report.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Report is a machine-readable form of a failed Result, for aggregating test
+// failures. It can be marshaled to JSON, or converted to a JUnit <failure>
+// element with JUnit.
+type Report struct {
+	// Test is the name of the test, if known.
+	Test string `json:"test,omitempty"`
+	// Status is "Mismatch" or "DomainError".
+	Status string `json:"status"`
+	// Matchers lists the descriptions of the matchers, from the outermost one
+	// to the innermost one that failed. For a DomainError, Matchers[0] is the
+	// error message.
+	Matchers []string `json:"matchers"`
+	// Value describes the value under test.
+	Value string `json:"value"`
+	// Annotations are the extra attributes of the value reported by the
+	// matchers, outermost first.
+	Annotations []string `json:"annotations,omitempty"`
+	// Details holds extra information, such as the diff between the value and
+	// the expected value.
+	Details string `json:"details,omitempty"`
+	// Message is the message passed to assert.That or expect.That.
+	Message string `json:"message,omitempty"`
+	// File and Line locate the caller of assert.That or expect.That.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Backtrace is the stack backtrace of the failure, with the frames of the
+	// testutil and testing packages removed.
+	Backtrace []Frame `json:"backtrace,omitempty"`
+}
+
+// The function name prefixes of the testutil packages.
+var (
+	hPkgPrefix      = reflect.TypeOf(Result{}).PkgPath() + "." // ".../testutil/h."
+	rootPkgPath     = strings.TrimSuffix(hPkgPrefix, "/h.")
+	assertPkgPrefix = rootPkgPath + "/assert."
+	expectPkgPrefix = rootPkgPath + "/expect."
+)
+
+var (
+	internalPkgMu sync.RWMutex
+	// internalPkgPrefixes lists the function name prefixes of the frames that
+	// are removed from backtraces. RegisterInternalPackage adds to it.
+	internalPkgPrefixes = []string{hPkgPrefix, assertPkgPrefix, expectPkgPrefix, "runtime.", "testing."}
+)
+
+// RegisterInternalPackage marks the functions of the package at pkgPath as
+// internal, like those of this package: their frames are removed from
+// backtraces, and failures are reported at their callers. Libraries built on
+// this package call it from init.
+//
+// Example:
+//   func init() {
+//     h.RegisterInternalPackage(reflect.TypeOf(Config{}).PkgPath())
+//   }
+func RegisterInternalPackage(pkgPath string) {
+	internalPkgMu.Lock()
+	internalPkgPrefixes = append(internalPkgPrefixes, pkgPath+".")
+	internalPkgMu.Unlock()
+}
+
+// isInternalFrame checks if the frame belongs to the testutil matcher packages,
+// to a package registered by RegisterInternalPackage, or to the Go runtime.
+func isInternalFrame(function string) bool {
+	internalPkgMu.RLock()
+	defer internalPkgMu.RUnlock()
+	for _, prefix := range internalPkgPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// userFrames converts a backtrace to frames, removing internal ones and the
+// first skip user frames.
+func userFrames(pcs []uintptr, skip int) []Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var result []Frame
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, ok := frames.Next()
+		if !isInternalFrame(frame.Function) {
+			if skip > 0 {
+				skip--
+			} else {
+				result = append(result, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+			}
+		}
+		if !ok {
+			break
+		}
+	}
+	return result
+}
+
+// Report converts the result to its machine-readable form. It returns nil if
+// the status is Match.
+func (r Result) Report() *Report {
+	if r.status == Match {
+		return nil
+	}
+	rep := &Report{
+		Status:   r.status.String(),
+		Matchers: []string{r.msg},
+		Value:    describe(r.value),
+		Details:  r.extra,
+	}
+	if r.extraFunc != nil {
+		rep.Details = r.extraFunc()
+	}
+	for i := len(r.nested) - 1; i >= 0; i-- {
+		rep.Matchers = append(rep.Matchers, r.nested[i])
+	}
+	for i := len(r.valueAnnotations) - 1; i >= 0; i-- {
+		rep.Annotations = append(rep.Annotations, r.valueAnnotations[i])
+	}
+	rep.Backtrace = userFrames(r.backtrace, r.skip)
+	if len(rep.Backtrace) > 0 {
+		rep.File, rep.Line = rep.Backtrace[0].File, rep.Backtrace[0].Line
+	}
+	return rep
+}
+
+// JUnitFailure is a JUnit XML <failure> element.
+type JUnitFailure struct {
+	XMLName xml.Name `xml:"failure"`
+	Message string   `xml:"message,attr"`
+	Type    string   `xml:"type,attr"`
+	Text    string   `xml:",chardata"`
+}
+
+// JUnit converts the report to a JUnit <failure> element. The message
+// attribute holds the location and the expectation, and the text holds the
+// full report.
+func (rep *Report) JUnit() JUnitFailure {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf("Actual:   %s", rep.Value))
+	if len(rep.Annotations) > 0 {
+		buf.WriteString(" " + strings.Join(rep.Annotations, ", "))
+	}
+	buf.WriteString(fmt.Sprintf("\nExpected: %s\n", rep.Matchers[0]))
+	if rep.Message != "" {
+		buf.WriteString(rep.Message + "\n")
+	}
+	if rep.Details != "" {
+		buf.WriteString("\n" + rep.Details)
+	}
+	if len(rep.Backtrace) > 0 {
+		buf.WriteString("\n")
+		for _, f := range rep.Backtrace {
+			buf.WriteString(fmt.Sprintf("%s:%d: %s\n", f.File, f.Line, f.Function))
+		}
+	}
+	msg := rep.Matchers[0]
+	if rep.File != "" {
+		msg = fmt.Sprintf("%s:%d: %s", rep.File, rep.Line, msg)
+	}
+	return JUnitFailure{Message: msg, Type: rep.Status, Text: buf.String()}
+}
+
+// Reporter receives the failures detected by assert.That and expect.That, in
+// addition to the failure messages logged to the test. Implementations must
+// be safe for concurrent use.
+type Reporter interface {
+	Report(rep *Report)
+}
+
+var (
+	reporterMu sync.Mutex
+	reporter   Reporter
+)
+
+// SetReporter installs a reporter, and returns a function that restores the
+// previous one. Passing nil disables reporting.
+//
+// Example:
+//   func TestMain(m *testing.M) {
+//     f, _ := os.Create("failures.json")
+//     h.SetReporter(h.NewJSONReporter(f))
+//     os.Exit(m.Run())
+//   }
+func SetReporter(r Reporter) (restore func()) {
+	reporterMu.Lock()
+	old := reporter
+	reporter = r
+	reporterMu.Unlock()
+	return func() {
+		reporterMu.Lock()
+		reporter = old
+		reporterMu.Unlock()
+	}
+}
+
+// ReportFailure sends a failed result to the installed reporter, if any. t is
+// the test, and msg is the message given by the user. It is called by
+// assert.That and expect.That.
+func ReportFailure(t interface{}, r Result, msg string) {
+	reporterMu.Lock()
+	rr := reporter
+	reporterMu.Unlock()
+	if rr == nil || r.status == Match {
+		return
+	}
+	rep := r.Report()
+	if tn, ok := t.(interface{ Name() string }); ok {
+		rep.Test = tn.Name()
+	}
+	rep.Message = msg
+	rr.Report(rep)
+}
+
+// writerReporter serializes reports to a writer.
+type writerReporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	encode func(w io.Writer, rep *Report) error
+}
+
+func (wr *writerReporter) Report(rep *Report) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	if err := wr.encode(wr.w, rep); err != nil {
+		panic(fmt.Sprintf("h: failed to write report: %v", err))
+	}
+}
+
+// NewJSONReporter creates a reporter that writes every failure to w as a JSON
+// object on its own line.
+func NewJSONReporter(w io.Writer) Reporter {
+	return &writerReporter{w: w, encode: func(w io.Writer, rep *Report) error {
+		return json.NewEncoder(w).Encode(rep)
+	}}
+}
+
+// junitTestCase is a JUnit <testcase> element holding one failure.
+type junitTestCase struct {
+	XMLName   xml.Name     `xml:"testcase"`
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr,omitempty"`
+	Failure   JUnitFailure `xml:"failure"`
+}
+
+// NewJUnitReporter creates a reporter that writes every failure to w as a
+// JUnit <testcase> element holding a <failure>, one per line. The elements can
+// be spliced into a <testsuite> by the test runner.
+func NewJUnitReporter(w io.Writer) Reporter {
+	return &writerReporter{w: w, encode: func(w io.Writer, rep *Report) error {
+		tc := junitTestCase{Name: rep.Test, Failure: rep.JUnit()}
+		if len(rep.Backtrace) > 0 {
+			// The class name is the package of the test function.
+			fn := rep.Backtrace[0].Function
+			slash := strings.LastIndex(fn, "/")
+			if dot := strings.Index(fn[slash+1:], "."); dot > 0 {
+				tc.ClassName = fn[:slash+1+dot]
+			}
+		}
+		data, err := xml.Marshal(tc)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}}
+}
@@ -0,0 +1,91 @@
+package h_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+// namedTester records the failures reported to it.
+type namedTester struct {
+	name string
+	msgs []string
+}
+
+func (t *namedTester) Error(args ...interface{}) { t.msgs = append(t.msgs, args[0].(string)) }
+func (t *namedTester) Name() string              { return t.name }
+
+func TestResultReport(t *testing.T) {
+	expect.That(t, h.EQ(1).Match(1).Report(), h.Nil())
+
+	rep := h.ElementsAre(h.Field("x", 1), h.Field("x", 3)).Match([]struct{ x int }{{1}, {2}}).Report()
+	expect.EQ(t, rep.Status, "Mismatch")
+	expect.That(t, rep.Matchers, h.ElementsAre(
+		h.HasPrefix("elements are"), "has field x that is (int)3", "(int)3"))
+	expect.That(t, rep.Annotations, h.ElementsAre(h.HasSubstr("element #1"), h.HasPrefix("whose field x")))
+	expect.That(t, rep.File, h.Regexp("report_test.go$"))
+	expect.That(t, rep.Backtrace, h.Each(h.Field("Function", h.Not(h.Regexp(`testutil/(h|expect)\.`)))))
+	expect.EQ(t, rep.Backtrace[0].Function, "github.com/grailbio/testutil/h_test.TestResultReport")
+
+	rep = h.LT("x").Match(1).Report()
+	expect.EQ(t, rep.Status, "DomainError")
+	expect.That(t, rep.Matchers[0], h.HasSubstr("not comparable"))
+
+	rep = h.EQ([]int{1, 2}).Match([]int{1, 3}).Report()
+	expect.That(t, rep.Details, h.HasSubstr("[1]: got 3, want 2"))
+}
+
+func TestJSONReporter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer h.SetReporter(h.NewJSONReporter(buf))()
+
+	tt := &namedTester{name: "TestFoo"}
+	expect.That(tt, 1, h.EQ(1))
+	expect.That(tt, 1, h.EQ(2), "check %d", 10)
+	expect.That(t, tt.msgs, h.Len(1))
+
+	var rep h.Report
+	expect.NoError(t, json.Unmarshal(buf.Bytes(), &rep))
+	expect.EQ(t, rep.Test, "TestFoo")
+	expect.EQ(t, rep.Message, "check 10")
+	expect.EQ(t, rep.Matchers, []string{"(int)2"})
+	expect.EQ(t, rep.Value, "(int)1")
+	expect.That(t, rep.File, h.Regexp("report_test.go$"))
+	expect.GT(t, rep.Line, 0)
+}
+
+func TestJUnitReporter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer h.SetReporter(h.NewJUnitReporter(buf))()
+
+	tt := &namedTester{name: "TestBar"}
+	expect.That(tt, "abc", h.HasSubstr("x"))
+	expect.That(t, buf.String(), h.HasPrefix(`<testcase name="TestBar" classname="github.com/grailbio/testutil/h_test"><failure message="`))
+
+	var tc struct {
+		Name    string `xml:"name,attr"`
+		Failure struct {
+			Message string `xml:"message,attr"`
+			Type    string `xml:"type,attr"`
+			Text    string `xml:",chardata"`
+		} `xml:"failure"`
+	}
+	expect.NoError(t, xml.Unmarshal([]byte(strings.TrimSpace(buf.String())), &tc))
+	expect.That(t, tc.Failure.Message, h.Regexp("report_test.go:[0-9]+: has substring `x`$"))
+	expect.EQ(t, tc.Failure.Type, "Mismatch")
+	expect.That(t, tc.Failure.Text, h.HasPrefix("Actual:   (string)abc\nExpected: has substring `x`\n"))
+
+	// Reporting stops when the reporter is removed.
+	buf.Reset()
+	restore := h.SetReporter(nil)
+	expect.That(tt, "abc", h.HasSubstr("x"))
+	expect.EQ(t, buf.Len(), 0)
+	restore()
+	expect.That(tt, "abc", h.HasSubstr("x"))
+	expect.GT(t, buf.Len(), 0)
+}
@@ -0,0 +1,146 @@
+package h
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// failureSite finds the first frame of a backtrace outside the testutil
+// packages, after skipping skip such frames, which is usually the line of the
+// test that failed. callee is the function that the frame called, e.g.,
+// "github.com/grailbio/testutil/assert.EQ".
+func failureSite(pcs []uintptr, skip int) (site Frame, callee string, ok bool) {
+	if len(pcs) == 0 {
+		return Frame{}, "", false
+	}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if !isInternalFrame(frame.Function) {
+			if skip == 0 {
+				return Frame{Function: frame.Function, File: frame.File, Line: frame.Line}, callee, true
+			}
+			skip--
+		}
+		callee = frame.Function
+		if !more {
+			return Frame{}, "", false
+		}
+	}
+}
+
+// isAssertionFunc checks if fn is a function or a method of package assert or
+// expect.
+func isAssertionFunc(fn string) bool {
+	return strings.HasPrefix(fn, assertPkgPrefix) || strings.HasPrefix(fn, expectPkgPrefix)
+}
+
+// parsedFile is a Go source file parsed by parseSource.
+type parsedFile struct {
+	fset *token.FileSet
+	file *ast.File
+	src  []byte
+}
+
+var parsedFiles sync.Map // path -> *parsedFile, or nil if the file can't be parsed.
+
+func parseSource(path string) *parsedFile {
+	if v, ok := parsedFiles.Load(path); ok {
+		return v.(*parsedFile)
+	}
+	var pf *parsedFile
+	if src, err := ioutil.ReadFile(path); err == nil {
+		fset := token.NewFileSet()
+		if file, err := parser.ParseFile(fset, path, src, 0); err == nil {
+			pf = &parsedFile{fset, file, src}
+		}
+	}
+	parsedFiles.Store(path, pf)
+	return pf
+}
+
+// findCall finds the innermost call of a function named name that spans the
+// given line.
+func (pf *parsedFile) findCall(line int, name string) *ast.CallExpr {
+	var found *ast.CallExpr
+	ast.Inspect(pf.file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if pf.fset.Position(n.Pos()).Line > line || pf.fset.Position(n.End()).Line < line {
+			return false
+		}
+		if call, ok := n.(*ast.CallExpr); ok {
+			var fn string
+			switch f := call.Fun.(type) {
+			case *ast.SelectorExpr:
+				fn = f.Sel.Name
+			case *ast.Ident:
+				fn = f.Name
+			}
+			if fn == name {
+				found = call
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// text returns the source text of a node.
+func (pf *parsedFile) text(n ast.Node) string {
+	return string(pf.src[pf.fset.Position(n.Pos()).Offset:pf.fset.Position(n.End()).Offset])
+}
+
+// describeSite describes the line of the test that failed, followed by the
+// source of the failed assertion and the value of the expression under test,
+// in the style of power-assert:
+//
+//   /path/foo_test.go:42: assert.EQ(t, resp.Code, 200)
+//   	resp.Code = (int)404
+func describeSite(site Frame, callee string, value interface{}) string {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("%s:%d:", site.File, site.Line))
+	pf := parseSource(site.File)
+	if pf == nil {
+		buf.WriteByte('\n')
+		return buf.String()
+	}
+	var call *ast.CallExpr
+	if isAssertionFunc(callee) {
+		call = pf.findCall(site.Line, callee[strings.LastIndex(callee, ".")+1:])
+	}
+	if call == nil {
+		// Show the source line as is.
+		lines := bytes.Split(pf.src, []byte("\n"))
+		if site.Line <= len(lines) {
+			buf.WriteString(" " + strings.TrimSpace(string(lines[site.Line-1])))
+		}
+		buf.WriteByte('\n')
+		return buf.String()
+	}
+	buf.WriteString(" " + pf.text(call) + "\n")
+	// The value under test is the second argument of the assertion functions,
+	// after the test, but the first argument of the methods, such as
+	// expect.(*GroupTB).That.
+	valueArg := 1
+	if strings.Contains(callee, ").") {
+		valueArg = 0
+	}
+	if len(call.Args) > valueArg {
+		switch call.Args[valueArg].(type) {
+		case *ast.BasicLit, *ast.CompositeLit:
+			// The value is evident from the source.
+		default:
+			buf.WriteString(fmt.Sprintf("\t%s = %s\n", pf.text(call.Args[valueArg]), describe(value)))
+		}
+	}
+	return buf.String()
+}
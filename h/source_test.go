@@ -0,0 +1,54 @@
+package h_test
+
+import (
+	"testing"
+
+	"github.com/grailbio/testutil/assert"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+type fatalTester struct{ namedTester }
+
+func (t *fatalTester) Fatal(args ...interface{}) { t.msgs = append(t.msgs, args[0].(string)) }
+
+func checkSmall(t expect.TB, x int) {
+	expect.LT(t, x, 3)
+}
+
+func TestFailureSite(t *testing.T) {
+	tt := &namedTester{}
+	resp := struct{ Code int }{404}
+	expect.EQ(tt, resp.Code, 200)
+	expect.That(t, tt.msgs, h.ElementsAre(h.Regexp(
+		`^/\S+/source_test.go:\d+: expect.EQ\(tt, resp.Code, 200\)\n\tresp.Code = \(int\)404\nActual:   \(int\)404\nExpected: \(int\)200\n`)))
+	expect.That(t, tt.msgs[0], h.Not(h.HasSubstr("Backtrace")))
+
+	ft := &fatalTester{}
+	assert.That(ft, []int{1, 2}, h.ElementsAre(1, 3))
+	expect.That(t, ft.msgs, h.ElementsAre(h.Regexp(
+		`source_test.go:\d+: assert.That\(ft, \[\]int\{1, 2\}, h.ElementsAre\(1, 3\)\)\nActual:`)))
+
+	// A failure in a helper shows the backtrace up to the test.
+	tt = &namedTester{}
+	checkSmall(tt, 5)
+	expect.That(t, tt.msgs, h.ElementsAre(h.Regexp(
+		`source_test.go:\d+: expect.LT\(t, x, 3\)\n\tx = \(int\)5\n(.|\n)*Backtrace:\n\t\S+source_test.go:\d+: \S+h_test.checkSmall\n\t\S+source_test.go:\d+: \S+h_test.TestFailureSite\n`)))
+
+	// Results checked outside of assert and expect show the source line.
+	r := h.EQ(1).Match(2)
+	expect.That(t, r.String(), h.Regexp(`source_test.go:\d+: r := h.EQ\(1\).Match\(2\)\nActual:`))
+	expect.That(t, r.Backtrace(), h.Not(h.Regexp(`runtime\.|testing\.|testutil/h\.`)))
+}
+
+func matchSmall(x int) h.Result {
+	return h.LT(3).Match(x)
+}
+
+func TestSkipCallers(t *testing.T) {
+	r := matchSmall(5).SkipCallers(1)
+	expect.That(t, r.String(), h.Regexp(`^\S+source_test.go:\d+: r := matchSmall\(5\).SkipCallers\(1\)\nActual:`))
+	expect.That(t, r.Backtrace(), h.Not(h.HasSubstr("matchSmall")))
+	expect.That(t, r.Report().Backtrace[0].Function, h.HasSuffix("TestSkipCallers"))
+	expect.That(t, func() { r.SkipCallers(-1) }, h.Panics(h.HasSubstr("n < 0")))
+}
@@ -0,0 +1,331 @@
+package h
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringOf converts the value under test to a string. A []byte is converted
+// as is, and other values are converted with fmt.Sprintf("%v").
+func stringOf(got interface{}) string {
+	switch got := got.(type) {
+	case string:
+		return got
+	case []byte:
+		return string(got)
+	}
+	return fmt.Sprintf("%v", got)
+}
+
+// stringPredicate creates a matcher that applies pred to the value under test,
+// converted with stringOf.
+func stringPredicate(msg, notMsg string, pred func(s string) bool) *Matcher {
+	m := &Matcher{Msg: msg, NotMsg: notMsg}
+	m.Match = func(got interface{}) Result {
+		return NewResult(pred(stringOf(got)), got, m.Msg)
+	}
+	return m
+}
+
+// HasSuffix checks if the value ends with the given substring. If the target
+// value is not a string, it is converted to a string with fmt.Sprintf("%v").
+func HasSuffix(want string) *Matcher {
+	return stringPredicate(fmt.Sprintf("has suffix `%s`", want), fmt.Sprintf("doesn't have suffix `%s`", want),
+		func(s string) bool { return strings.HasSuffix(s, want) })
+}
+
+// EqualFold checks if the value is equal to want under Unicode case folding,
+// i.e., ignoring case. If the target value is not a string, it is converted to
+// a string with fmt.Sprintf("%v").
+//
+// Example:
+//   assert.That(t, "Hello", h.EqualFold("HELLO"))
+func EqualFold(want string) *Matcher {
+	return stringPredicate(fmt.Sprintf("equals `%s` ignoring case", want), fmt.Sprintf("doesn't equal `%s` ignoring case", want),
+		func(s string) bool { return strings.EqualFold(s, want) })
+}
+
+// HasSubstrFold is a case-insensitive version of HasSubstr.
+func HasSubstrFold(want string) *Matcher {
+	return stringPredicate(fmt.Sprintf("has substring `%s` ignoring case", want), fmt.Sprintf("doesn't have substring `%s` ignoring case", want),
+		func(s string) bool { return containsFold(s, want) })
+}
+
+// HasPrefixFold is a case-insensitive version of HasPrefix.
+func HasPrefixFold(want string) *Matcher {
+	return stringPredicate(fmt.Sprintf("has prefix `%s` ignoring case", want), fmt.Sprintf("doesn't have prefix `%s` ignoring case", want),
+		func(s string) bool { return hasPrefixFold(s, want) })
+}
+
+// HasSuffixFold is a case-insensitive version of HasSuffix.
+func HasSuffixFold(want string) *Matcher {
+	return stringPredicate(fmt.Sprintf("has suffix `%s` ignoring case", want), fmt.Sprintf("doesn't have suffix `%s` ignoring case", want),
+		func(s string) bool { return hasSuffixFold(s, want) })
+}
+
+// runesLen returns the length in bytes of the first n runes of s, or -1 if s
+// has fewer runes. The fold matchers compare windows of s with as many runes as
+// want, since case folding maps runes to runes, but not bytes to bytes: the
+// Kelvin sign "\u212a" folds to "k", for instance.
+func runesLen(s string, n int) int {
+	i := 0
+	for ; n > 0; n-- {
+		if i == len(s) {
+			return -1
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return i
+}
+
+// hasPrefixFold checks if s starts with prefix under Unicode case folding.
+func hasPrefixFold(s, prefix string) bool {
+	i := runesLen(s, utf8.RuneCountInString(prefix))
+	return i >= 0 && strings.EqualFold(s[:i], prefix)
+}
+
+// hasSuffixFold checks if s ends with suffix under Unicode case folding.
+func hasSuffixFold(s, suffix string) bool {
+	i := len(s)
+	for n := utf8.RuneCountInString(suffix); n > 0; n-- {
+		if i == 0 {
+			return false
+		}
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return strings.EqualFold(s[i:], suffix)
+}
+
+// containsFold checks if s contains substr under Unicode case folding.
+func containsFold(s, substr string) bool {
+	n := utf8.RuneCountInString(substr)
+	for i := 0; ; {
+		j := runesLen(s[i:], n)
+		if j < 0 {
+			return false
+		}
+		if strings.EqualFold(s[i:i+j], substr) {
+			return true
+		}
+		if i == len(s) {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+}
+
+// globToRegexp converts a glob pattern to an anchored regexp.
+func globToRegexp(pattern string) (string, error) {
+	buf := strings.Builder{}
+	buf.WriteString("^(?s:")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			buf.WriteString(".*")
+		case '?':
+			buf.WriteString(".")
+		case '\\':
+			if i+1 == len(pattern) {
+				return "", fmt.Errorf("trailing backslash")
+			}
+			i++
+			buf.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end + 1
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString(")$")
+	return buf.String(), nil
+}
+
+// Glob checks if the whole value matches a shell glob pattern. '*' matches any
+// sequence of characters, including '/' and newlines, '?' matches any single
+// character, "[abc]", "[a-z]" and "[!abc]" match a character class, and '\'
+// escapes the next character. If the target value is not a string, it is
+// converted to a string with fmt.Sprintf("%v").
+//
+// Example:
+//   assert.That(t, "s3://bucket/dir/file.txt", h.Glob("s3://bucket/*.txt"))
+func Glob(pattern string) *Matcher {
+	reStr, err := globToRegexp(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("h.Glob: invalid pattern %q: %v", pattern, err))
+	}
+	re, err := regexp.Compile(reStr)
+	if err != nil {
+		panic(fmt.Sprintf("h.Glob: invalid pattern %q: %v", pattern, err))
+	}
+	return stringPredicate(fmt.Sprintf("matches glob `%s`", pattern), fmt.Sprintf("doesn't match glob `%s`", pattern),
+		re.MatchString)
+}
+
+// normalizeSpace replaces every run of whitespace by a single space, and
+// removes the leading and trailing whitespace.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// EqualIgnoringWhitespace checks if the value equals want when every run of
+// whitespace is treated as a single space, and leading and trailing whitespace
+// is ignored. If the target value is not a string, it is converted to a string
+// with fmt.Sprintf("%v").
+//
+// Example:
+//   assert.That(t, "SELECT *\n  FROM t ", h.EqualIgnoringWhitespace("SELECT * FROM t"))
+func EqualIgnoringWhitespace(want string) *Matcher {
+	nw := normalizeSpace(want)
+	return stringPredicate(fmt.Sprintf("equals %q ignoring whitespace", nw), fmt.Sprintf("doesn't equal %q ignoring whitespace", nw),
+		func(s string) bool { return normalizeSpace(s) == nw })
+}
+
+// splitLines splits s into lines. Line terminators, "\n" or "\r\n", are
+// removed, and a trailing terminator doesn't start a new line.
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// Lines splits the value into lines, and checks if the []string of lines
+// matches want. want is usually a matcher for slices, such as ElementsAre or
+// Contains. If the target value is not a string, it is converted to a string
+// with fmt.Sprintf("%v").
+//
+// Example:
+//   assert.That(t, out, h.Lines(h.ElementsAre("total 2", h.HasPrefix("-rw"), h.HasPrefix("-rw"))))
+func Lines(want interface{}) *Matcher {
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("lines %s", wm.Msg),
+		NotMsg: fmt.Sprintf("lines %s", wm.NotMsg),
+	}
+	m.Match = func(got interface{}) Result {
+		lines := splitLines(stringOf(got))
+		switch r := wm.Match(lines); r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(got, m, fmt.Sprintf("whose lines %s don't match", describe(lines)))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// stringDiffContext is the number of bytes shown around the first difference
+// by StringEQ.
+const stringDiffContext = 20
+
+// firstDifference returns the byte offset of the first rune that differs
+// between x and y.
+func firstDifference(x, y string) int {
+	i := 0
+	for i < len(x) && i < len(y) && x[i] == y[i] {
+		i++
+	}
+	for i > 0 && i < len(x) && !utf8.RuneStart(x[i]) {
+		i--
+	}
+	return i
+}
+
+// excerpt returns the part of s around offset, quoted, and the column of
+// offset in the excerpt.
+func excerpt(s string, offset int) (string, int) {
+	start, end := offset-stringDiffContext, offset+stringDiffContext
+	prefix, suffix := "", ""
+	if start <= 0 {
+		start = 0
+	} else {
+		for start < offset && !utf8.RuneStart(s[start]) {
+			start++
+		}
+		prefix = "..."
+	}
+	if end >= len(s) {
+		end = len(s)
+	} else {
+		for end > offset && !utf8.RuneStart(s[end]) {
+			end--
+		}
+		suffix = "..."
+	}
+	quotedHead := strconv.Quote(s[start:offset])
+	col := len(prefix) + utf8.RuneCountInString(quotedHead) - 1
+	return prefix + strconv.Quote(s[start:end]) + suffix, col
+}
+
+// describeStringDiff shows the first difference between got and want.
+func describeStringDiff(got, want string) string {
+	off := firstDifference(got, want)
+	line := strings.Count(got[:off], "\n") + 1
+	lineStart := strings.LastIndex(got[:off], "\n") + 1
+	col := utf8.RuneCountInString(got[lineStart:off]) + 1
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("First difference at offset %d (line %d, column %d):\n", off, line, col))
+	// The strings are the same up to off, so the caret is at the same column
+	// in both excerpts.
+	gotEx, caretCol := excerpt(got, off)
+	wantEx, _ := excerpt(want, off)
+	buf.WriteString("  got:  " + gotEx + "\n")
+	buf.WriteString("  want: " + wantEx + "\n")
+	buf.WriteString("        " + strings.Repeat(" ", caretCol) + "^\n")
+	if strings.Contains(got, "\n") || strings.Contains(want, "\n") {
+		buf.WriteString("\n" + diffLines(got, want))
+	}
+	return buf.String()
+}
+
+// StringEQ checks if the value is a string equal to want. Unlike EQ, on
+// mismatch it shows the first differing offset, with the surrounding
+// characters of both strings, and a line diff if the strings span multiple
+// lines. The target value must be a string or a []byte.
+//
+// Example:
+//   assert.That(t, got, h.StringEQ("hello world"))
+func StringEQ(want string) *Matcher {
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is %q", want),
+		NotMsg: fmt.Sprintf("is not %q", want),
+	}
+	m.Match = func(got interface{}) Result {
+		var s string
+		switch g := got.(type) {
+		case string:
+			s = g
+		case []byte:
+			s = string(g)
+		default:
+			return NewErrorf(got, "StringEQ: %s must be a string or a []byte", describeVerbose(got))
+		}
+		r := NewResult(s == want, got, m.Msg)
+		if r.status != Match {
+			r.extraFunc = func() string { return describeStringDiff(s, want) }
+		}
+		return r
+	}
+	return m
+}
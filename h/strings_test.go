@@ -0,0 +1,99 @@
+package h_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestCaseInsensitiveMatchers(t *testing.T) {
+	expect.That(t, "foobar", h.HasSuffix("bar"))
+	expect.That(t, []byte("foobar"), h.HasSuffix("bar"))
+	expect.That(t, "foobar", h.Not(h.HasSuffix("Bar")))
+	expect.That(t, h.HasSuffix("x").Match("abc"), resultIs(h.Mismatch, "Expected: has suffix `x`"))
+
+	expect.That(t, "Hello", h.EqualFold("hELLO"))
+	expect.That(t, "Hello", h.Not(h.EqualFold("hell")))
+	expect.That(t, "Hello World", h.HasSubstrFold("o w"))
+	expect.That(t, "Hello World", h.HasPrefixFold("HELLO"))
+	expect.That(t, "Hello World", h.HasSuffixFold("WORLD"))
+	expect.That(t, "Hi", h.Not(h.HasSuffixFold("WORLD")))
+	// The Kelvin sign folds to k, and the long s to s, but they are longer in
+	// UTF-8.
+	expect.That(t, "\u212aelvin", h.HasPrefixFold("KEL"))
+	expect.That(t, "\u212aelvin", h.Not(h.HasPrefixFold("KELVINS")))
+	expect.That(t, "in \u212a", h.HasSuffixFold(" k"))
+	expect.That(t, "k", h.HasSuffixFold("\u212a"))
+	expect.That(t, "\u212a", h.Not(h.HasSuffixFold("kk")))
+	expect.That(t, "a \u017ftop", h.HasSubstrFold("STOP"))
+	expect.That(t, "a \u017ftop", h.Not(h.HasSubstrFold("STOPS")))
+	expect.That(t, "éa", h.HasPrefixFold("É"))
+	expect.That(t, "ÉA", h.Not(h.HasPrefixFold("e")))
+	expect.That(t, h.EqualFold("x").Match("y"), resultIs(h.Mismatch, "Expected: equals `x` ignoring case"))
+}
+
+func TestGlob(t *testing.T) {
+	expect.That(t, "s3://bucket/dir/file.txt", h.Glob("s3://bucket/*.txt"))
+	expect.That(t, "a.b", h.Glob("a?b"))
+	expect.That(t, "a1", h.Glob("a[0-9]"))
+	expect.That(t, "ax", h.Not(h.Glob("a[!x]")))
+	expect.That(t, "a*b", h.Glob(`a\*b`))
+	expect.That(t, "a(b)", h.Glob("a(*)"))
+	expect.That(t, "xab", h.Not(h.Glob("ab")))
+	expect.That(t, h.Glob("*.go").Match("x.txt"), resultIs(h.Mismatch, "Expected: matches glob `\\*.go`"))
+	expect.That(t, func() { h.Glob("[ab") }, h.Panics(h.HasSubstr("unterminated")))
+}
+
+func TestEqualIgnoringWhitespace(t *testing.T) {
+	expect.That(t, "SELECT *\n  FROM t ", h.EqualIgnoringWhitespace("SELECT * FROM t"))
+	expect.That(t, "ab", h.Not(h.EqualIgnoringWhitespace("a b")))
+	expect.That(t, h.EqualIgnoringWhitespace(" a\tb ").Match("a c"),
+		resultIs(h.Mismatch, `Expected: equals "a b" ignoring whitespace`))
+}
+
+func TestLines(t *testing.T) {
+	out := "total 2\r\n-rw a\n-rw b\n"
+	expect.That(t, out, h.Lines(h.ElementsAre("total 2", h.HasPrefix("-rw"), h.HasPrefix("-rw"))))
+	expect.That(t, out, h.Lines(h.Contains("-rw b")))
+	expect.That(t, "", h.Lines(h.IsEmpty()))
+	expect.That(t, h.Lines(h.ElementsAre("a", "c")).Match("a\nb"),
+		resultIs(h.Mismatch, `whose lines \(\[\]string\)\[a b\] don't match, whose element #1 doesn't match\nExpected: lines elements are`))
+}
+
+func TestStringEQ(t *testing.T) {
+	expect.That(t, "hello", h.StringEQ("hello"))
+	expect.That(t, []byte("hello"), h.StringEQ("hello"))
+	expect.That(t, "hello", h.Not(h.StringEQ("hellO")))
+
+	r := h.StringEQ("hello there").Match("hello world")
+	expect.That(t, r, resultIs(h.Mismatch, `Expected: is "hello there"`))
+	expect.HasSubstr(t, r, `First difference at offset 6 (line 1, column 7):
+  got:  "hello world"
+  want: "hello there"
+               ^
+`)
+
+	long := strings.Repeat("a", 50)
+	r = h.StringEQ(long + "x" + long).Match(long + "y" + long)
+	expect.HasSubstr(t, r, `First difference at offset 50 (line 1, column 51):
+  got:  ..."aaaaaaaaaaaaaaaaaaaayaaaaaaaaaaaaaaaaaaa"...
+  want: ..."aaaaaaaaaaaaaaaaaaaaxaaaaaaaaaaaaaaaaaaa"...
+                                ^
+`)
+
+	r = h.StringEQ("line 1\nline 2\nline 3\n").Match("line 1\nline two\nline 3\n")
+	expect.HasSubstr(t, r, `First difference at offset 12 (line 2, column 6):`)
+	expect.HasSubstr(t, r, "- line two\n+ line 2\n")
+
+	r = h.StringEQ("héllo").Match("hêllo")
+	expect.HasSubstr(t, r, "First difference at offset 1 (line 1, column 2):")
+	r = h.StringEQ("héllo there").Match("héllo world")
+	expect.HasSubstr(t, r, `First difference at offset 7 (line 1, column 7):
+  got:  "héllo world"
+  want: "héllo there"
+               ^
+`)
+	expect.That(t, h.StringEQ("x").Match(1), resultIs(h.DomainError, "must be a string"))
+}
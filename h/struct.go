@@ -0,0 +1,126 @@
+package h
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// indirect follows pointers and interfaces until it reaches a value of another
+// kind. It returns an invalid value if it encounters a nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = readable(v.Elem())
+	}
+	return addressable(v)
+}
+
+// Field checks if a struct field matches the given value or matcher. The target
+// value must be a struct, or a pointer or an interface that refers to a struct.
+// The name may be a dotted path, in which case the fields are looked up
+// recursively. Unexported fields can also be matched.
+//
+// Example:
+//   assert.That(t, resp, h.Field("Status", h.EQ(200)))
+//   assert.That(t, resp, h.Field("Header.ContentType", h.HasPrefix("text/")))
+func Field(name string, want interface{}) *Matcher {
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("has field %s that %s", name, phrasify(wm)),
+		NotMsg: fmt.Sprintf("has field %s that %s", name, wm.NotMsg),
+	}
+	m.Match = func(got interface{}) Result {
+		v := reflect.ValueOf(got)
+		for _, part := range strings.Split(name, ".") {
+			v = indirect(v)
+			if !v.IsValid() {
+				return NewErrorf(got, "Field(%s): %s refers to a nil value", name, describeVerbose(got))
+			}
+			if v.Kind() != reflect.Struct {
+				return NewErrorf(got, "Field(%s): %s is not a struct", name, describeValue(v))
+			}
+			f := v.FieldByName(part)
+			if !f.IsValid() {
+				return NewErrorf(got, "Field(%s): type %v has no field %s", name, v.Type(), part)
+			}
+			v = readable(f)
+		}
+		fv := v.Interface()
+		r := wm.Match(fv)
+		switch r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(got, m, fmt.Sprintf("whose field %s %s doesn't match", name, describe(fv)))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+// funcName returns a short name of a function, for use in matcher messages.
+func funcName(fn reflect.Value) string {
+	name := runtime.FuncForPC(fn.Pointer()).Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// Property checks if the value returned by fn matches the given value or
+// matcher. fn must be a function of form func(T) U. The target value must be
+// assignable to T. Method expressions are convenient to use as fn.
+//
+// Example:
+//   assert.That(t, resp, h.Property((*Response).StatusCode, h.EQ(200)))
+//   assert.That(t, resp, h.Property(func(r *Response) int { return len(r.Body) }, h.GT(0)))
+func Property(fn interface{}, want interface{}) *Matcher {
+	fnV := reflect.ValueOf(fn)
+	if fnV.Kind() != reflect.Func || fnV.Type().NumIn() != 1 || fnV.Type().NumOut() != 1 {
+		panic(fmt.Sprintf("h.Property: %s must be a function of form func(T) U", describeVerbose(fn)))
+	}
+	argType := fnV.Type().In(0)
+	name := funcName(fnV)
+	wm := toMatcher(want)
+	m := &Matcher{
+		Msg:    fmt.Sprintf("has property %s that %s", name, phrasify(wm)),
+		NotMsg: fmt.Sprintf("has property %s that %s", name, wm.NotMsg),
+	}
+	m.Match = func(got interface{}) Result {
+		gotV := reflect.ValueOf(got)
+		if !gotV.IsValid() {
+			if !canBeNil(argType) {
+				return NewErrorf(got, "Property(%s): nil is not assignable to %v", name, argType)
+			}
+			gotV = reflect.Zero(argType)
+		}
+		if !gotV.Type().AssignableTo(argType) {
+			return NewErrorf(got, "Property(%s): %s is not assignable to %v", name, describeVerbose(got), argType)
+		}
+		pv := fnV.Call([]reflect.Value{gotV})[0].Interface()
+		r := wm.Match(pv)
+		switch r.status {
+		case DomainError:
+			return r
+		case Mismatch:
+			return r.Wrap(got, m, fmt.Sprintf("whose property %s %s doesn't match", name, describe(pv)))
+		}
+		return NewResult(true, got, m.Msg)
+	}
+	return m
+}
+
+func canBeNil(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package h_test
+
+import (
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+type header struct {
+	ContentType string
+	length      int
+}
+
+type response struct {
+	Status int
+	Header *header
+	Body   interface{}
+}
+
+func (r *response) OK() bool { return r.Status == 200 }
+
+func TestField(t *testing.T) {
+	resp := &response{Status: 404, Header: &header{ContentType: "text/plain", length: 10}}
+	expect.That(t, resp, h.Field("Status", 404))
+	expect.That(t, *resp, h.Field("Status", h.GE(400)))
+	expect.That(t, resp, h.Field("Header.ContentType", h.HasPrefix("text/")))
+	expect.That(t, resp, h.Field("Header.length", 10))
+	var i interface{} = resp
+	expect.That(t, i, h.Field("Status", h.Not(200)))
+
+	expect.That(t, h.Field("Status", h.EQ(200)).Match(resp),
+		resultIs(h.Mismatch, `(?s)Actual: .* whose field Status \(int\)404 doesn't match\nExpected: has field Status that is \(int\)200`))
+	expect.That(t, h.Not(h.Field("Status", 404)).Match(resp),
+		resultIs(h.Mismatch, `Expected: has field Status that is != \(int\)404`))
+	expect.That(t, h.Field("Status", h.EQ("x")).Match(resp),
+		resultIs(h.DomainError, "are not comparable"))
+	expect.That(t, h.Field("Missing", 1).Match(resp),
+		resultIs(h.DomainError, "has no field Missing"))
+	expect.That(t, h.Field("Status", 1).Match(10),
+		resultIs(h.DomainError, "is not a struct"))
+	expect.That(t, h.Field("Header.length", 1).Match(&response{}),
+		resultIs(h.DomainError, "refers to a nil value"))
+}
+
+func TestFieldNested(t *testing.T) {
+	resp := response{Body: response{Status: 1}}
+	expect.That(t, resp, h.Field("Body", h.Field("Status", 1)))
+	expect.That(t, h.Field("Body", h.Field("Status", 2)).Match(resp),
+		resultIs(h.Mismatch, `(?s)whose field Body .* doesn't match, whose field Status \(int\)1 doesn't match`))
+}
+
+func TestProperty(t *testing.T) {
+	resp := &response{Status: 404}
+	expect.That(t, resp, h.Property((*response).OK, false))
+	expect.That(t, resp, h.Property(func(r *response) int { return r.Status / 100 }, 4))
+	expect.That(t, h.Property((*response).OK, true).Match(resp),
+		resultIs(h.Mismatch, `(?s)whose property \(\*response\).OK \(bool\)false doesn't match\nExpected: has property \(\*response\).OK that is \(bool\)true`))
+	expect.That(t, h.Property((*response).OK, true).Match(10),
+		resultIs(h.DomainError, `is not assignable to \*h_test.response`))
+	expect.That(t, func() { h.Property(10, true) }, h.Panics(h.HasSubstr("must be a function")))
+}
@@ -0,0 +1,163 @@
+package h
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// compareTimes is the built-in comparator for time.Time. It compares the
+// instants, ignoring the location and the monotonic clock reading, so that
+// EQ, LT and similar matchers work on times, also inside structs.
+func compareTimes(a, b time.Time) (int, error) {
+	switch {
+	case a.Before(b):
+		return -1, nil
+	case a.After(b):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func init() {
+	builtinComparators[reflect.TypeOf(time.Time{})] = reflect.ValueOf(compareTimes)
+}
+
+// formatTime formats a time for matcher descriptions.
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// timeOf converts the value under test to a time.Time. It accepts a time.Time
+// or a *time.Time.
+func timeOf(label string, got interface{}) (time.Time, *Result) {
+	switch g := got.(type) {
+	case time.Time:
+		return g, nil
+	case *time.Time:
+		if g != nil {
+			return *g, nil
+		}
+	}
+	r := NewErrorf(got, "%s: %s must be a time.Time", label, describeVerbose(got))
+	return time.Time{}, &r
+}
+
+// timePredicate creates a matcher that applies pred to a time.Time value.
+func timePredicate(label, msg, notMsg string, pred func(t time.Time) bool) *Matcher {
+	m := &Matcher{Msg: msg, NotMsg: notMsg}
+	m.Match = func(got interface{}) Result {
+		v, r := timeOf(label, got)
+		if r != nil {
+			return *r
+		}
+		return NewResult(pred(v), got, m.Msg)
+	}
+	return m
+}
+
+// TimeEQ checks if the value is a time.Time that represents the same instant
+// as want, using time.Time.Equal. Unlike comparing with ==, the location and
+// the monotonic clock reading are ignored.
+//
+// Example:
+//   expect.That(t, got.UTC(), h.TimeEQ(want))
+func TimeEQ(want time.Time) *Matcher {
+	return timePredicate("TimeEQ", "is time "+formatTime(want), "is not time "+formatTime(want),
+		func(t time.Time) bool { return t.Equal(want) })
+}
+
+// Before checks if the value is a time.Time strictly before want.
+func Before(want time.Time) *Matcher {
+	return timePredicate("Before", "is before "+formatTime(want), "is not before "+formatTime(want),
+		func(t time.Time) bool { return t.Before(want) })
+}
+
+// After checks if the value is a time.Time strictly after want.
+func After(want time.Time) *Matcher {
+	return timePredicate("After", "is after "+formatTime(want), "is not after "+formatTime(want),
+		func(t time.Time) bool { return t.After(want) })
+}
+
+// absDuration returns |d|, saturating at the maximum duration.
+func absDuration(d time.Duration) time.Duration {
+	if d >= 0 {
+		return d
+	}
+	if -d < 0 { // math.MinInt64
+		return 1<<63 - 1
+	}
+	return -d
+}
+
+// durationDistance returns |a - b| in nanoseconds. Unlike a - b, it doesn't
+// overflow.
+func durationDistance(a, b time.Duration) uint64 {
+	if a < b {
+		a, b = b, a
+	}
+	return uint64(a) - uint64(b)
+}
+
+// formatDistance formats a distance returned by durationDistance.
+func formatDistance(d uint64) string {
+	if d > 1<<63-1 {
+		return fmt.Sprintf("%dns", d)
+	}
+	return time.Duration(d).String()
+}
+
+// WithinDuration checks if the value is a time.Time at most d away from want,
+// in either direction.
+//
+// Example:
+//   expect.That(t, file.ModTime(), h.WithinDuration(time.Now(), time.Minute))
+func WithinDuration(want time.Time, d time.Duration) *Matcher {
+	if d < 0 {
+		panic("h.WithinDuration: d < 0")
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is within %v of %s", d, formatTime(want)),
+		NotMsg: fmt.Sprintf("is not within %v of %s", d, formatTime(want)),
+	}
+	m.Match = func(got interface{}) Result {
+		v, r := timeOf("WithinDuration", got)
+		if r != nil {
+			return *r
+		}
+		delta := absDuration(v.Sub(want))
+		res := NewResult(delta <= d, got, m.Msg)
+		if res.status != Match {
+			res.valueAnnotations = append(res.valueAnnotations, fmt.Sprintf("which is %v away", delta))
+		}
+		return res
+	}
+	return m
+}
+
+// DurationNear checks if the value is a time.Duration within tol of want.
+//
+// Example:
+//   expect.That(t, elapsed, h.DurationNear(time.Second, 100*time.Millisecond))
+func DurationNear(want, tol time.Duration) *Matcher {
+	if tol < 0 {
+		panic("h.DurationNear: tol < 0")
+	}
+	m := &Matcher{
+		Msg:    fmt.Sprintf("is near %v within %v", want, tol),
+		NotMsg: fmt.Sprintf("is not near %v within %v", want, tol),
+	}
+	m.Match = func(got interface{}) Result {
+		v, ok := got.(time.Duration)
+		if !ok {
+			return NewErrorf(got, "DurationNear: %s must be a time.Duration", describeVerbose(got))
+		}
+		delta := durationDistance(v, want)
+		res := NewResult(delta <= uint64(tol), got, m.Msg)
+		if res.status != Match {
+			res.valueAnnotations = append(res.valueAnnotations, fmt.Sprintf("which is %v away", formatDistance(delta)))
+		}
+		return res
+	}
+	return m
+}
@@ -0,0 +1,78 @@
+package h_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func TestTimeEQ(t *testing.T) {
+	now := time.Now() // Has a monotonic clock reading.
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		ny = time.FixedZone("EST", -5*3600)
+	}
+	expect.That(t, now.In(ny), h.TimeEQ(now))
+	expect.That(t, now.Round(0), h.TimeEQ(now))
+	expect.That(t, &now, h.TimeEQ(now.UTC()))
+	expect.That(t, now.Add(time.Nanosecond), h.Not(h.TimeEQ(now)))
+	expect.That(t, h.TimeEQ(now).Match(now.Unix()), resultIs(h.DomainError, "TimeEQ: .* must be a time.Time"))
+}
+
+func TestTimeComparator(t *testing.T) {
+	now := time.Now()
+	type event struct {
+		Name string
+		At   time.Time
+	}
+	expect.That(t, now.UTC(), h.EQ(now))
+	expect.That(t, event{"x", now.UTC()}, h.EQ(event{"x", now.Round(0)}))
+	expect.That(t, event{"x", now}, h.NEQ(event{"x", now.Add(time.Second)}))
+	expect.That(t, now, h.LT(now.Add(time.Second)))
+	expect.That(t, now, h.GE(now.UTC()))
+	expect.That(t, []time.Time{now.Add(time.Hour), now}, h.UnorderedElementsAre(now.UTC(), h.GT(now)))
+
+	// Unregistering a comparator that overrides the built-in one restores it.
+	t0 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	byDay := func(a, b time.Time) (int, error) {
+		return int(a.Truncate(24*time.Hour).Sub(b.Truncate(24 * time.Hour))), nil
+	}
+	h.RegisterComparator(byDay)
+	expect.That(t, t0, h.EQ(t0.Add(time.Second)))
+	h.UnregisterComparator(byDay)
+	expect.That(t, t0, h.NEQ(t0.Add(time.Second)))
+	expect.That(t, t0, h.EQ(t0.In(time.FixedZone("X", 3600))))
+}
+
+func TestBeforeAfter(t *testing.T) {
+	t0 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	expect.That(t, t0, h.Before(t0.Add(time.Second)))
+	expect.That(t, t0, h.Not(h.Before(t0)))
+	expect.That(t, t0, h.After(t0.Add(-time.Second)))
+	expect.That(t, t0, h.Not(h.After(t0)))
+	expect.That(t, h.Before(t0).Match(t0), resultIs(h.Mismatch, `Expected: is before 2020-01-02T03:04:05Z`))
+	expect.That(t, h.After(t0).Match("x"), resultIs(h.DomainError, "After: .* must be a time.Time"))
+}
+
+func TestWithinDuration(t *testing.T) {
+	t0 := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	expect.That(t, t0.Add(time.Second), h.WithinDuration(t0, time.Second))
+	expect.That(t, t0.Add(-time.Second), h.WithinDuration(t0, time.Second))
+	expect.That(t, h.WithinDuration(t0, time.Second).Match(t0.Add(-2*time.Second)),
+		resultIs(h.Mismatch, `which is 2s away\nExpected: is within 1s of 2020-01-02T03:04:05Z`))
+	expect.That(t, func() { h.WithinDuration(t0, -1) }, h.Panics(h.HasSubstr("d < 0")))
+}
+
+func TestDurationNear(t *testing.T) {
+	expect.That(t, 950*time.Millisecond, h.DurationNear(time.Second, 100*time.Millisecond))
+	expect.That(t, h.DurationNear(time.Second, 100*time.Millisecond).Match(800*time.Millisecond),
+		resultIs(h.Mismatch, `which is 200ms away\nExpected: is near 1s within 100ms`))
+	expect.That(t, h.DurationNear(time.Second, 0).Match(1000), resultIs(h.DomainError, "must be a time.Duration"))
+	expect.That(t, h.DurationNear(math.MinInt64, time.Second).Match(time.Duration(math.MaxInt64)),
+		resultIs(h.Mismatch, `which is 18446744073709551615ns away`))
+	expect.That(t, time.Duration(-1), h.Not(h.DurationNear(math.MaxInt64, math.MaxInt64)))
+	expect.That(t, time.Duration(0), h.DurationNear(math.MaxInt64, math.MaxInt64))
+}
@@ -25,12 +25,43 @@
 //   	 PACKAGE.EQ(t, foo.DoBar(), []int{1, 2})
 //   }
 //
+// PACKAGE.Equal, PACKAGE.NotEqual and PACKAGE.ThatOf are type-safe versions of
+// PACKAGE.EQ, PACKAGE.NEQ and PACKAGE.That, which use generic h.Of[T] matchers:
+//
+//   	 PACKAGE.Equal(t, user.Age, 42)
+//   	 PACKAGE.ThatOf(t, user, h.Map("whose age", func(u User) int { return u.Age }, h.Less(65)))
+//
+// Shorthands such as PACKAGE.Contains, PACKAGE.ElementsAre, PACKAGE.Len,
+// PACKAGE.Panics and PACKAGE.FileContains exist for the common matchers of
+// package h.
+//
+// PACKAGE.Table runs a table-driven test, one subtest per case, and summarizes
+// the failed cases:
+//
+//   	 PACKAGE.Table(t, []PACKAGE.Case[string]{
+//   	   {Name: "empty", In: "", Want: 0},
+//   	   {Name: "word", In: "hello", Want: h.GT(0)},
+//   	 }, utf8.RuneCountInString, PACKAGE.ParallelCases(), PACKAGE.SkipCases("slow*"))
+//
 // The only difference between packages expect and assert is that expect.XXX
 // will call testing.T.Error on error, whereas assert.XXX will call
 // testing.T.Fatal on error. So you should use expect if the test code should
 // continue after a failure, but assert if the testcase should abort immediately
 // on failure. They can be mixed within one test.
 //
+// expect.Group collects the failures of many checks, and reports them as one
+// failure under a shared heading:
+//
+//   	 g := expect.Group(t, "response")
+//   	 expect.EQ(g, resp.Code, 200)
+//   	 g.That(resp.Body, h.HasSubstr("<title>"))
+//   	 g.Done() // Or g.DoneFatal() to stop the test on failure.
+//
+// expect.Before and expect.BeforeThat register expectations that are checked
+// when the test finishes, e.g., the number of calls made to a mock:
+//
+//   	 expect.BeforeThat(t, func() interface{} { return client.GetApiCount("GetObjectRequest") }, h.GE(1))
+//
 // Matchers:
 //
 // Most of the actual matching features are implemented in the
@@ -46,11 +77,32 @@
 //
 // Package testutil/h defines many common matchers.
 //
-// - h.LT, h.LE, h.GT, h.GE for arithmetic comparisons.
+// - h.LT, h.LE, h.GT, h.GE for arithmetic comparisons. h.InRange checks both
+//   bounds.
+//
+// - h.FloatNear, h.FloatRelNear, h.WithinULP, h.SliceFloatNear and
+//   h.ComplexNear compare numbers approximately. h.IsNaN and h.IsInf check
+//   special floats.
+//
+// - h.TimeEQ, h.Before, h.After and h.WithinDuration compare times, and
+//   h.DurationNear compares durations. h.EQ, h.LT and similar matchers
+//   compare time.Time values with time.Time.Equal and Before, also inside
+//   structs, ignoring the location and the monotonic clock reading.
 //
 // - h.EQ and h.NEQ to compare equality of two values.  They can be used not
 //   just for scalar values, but for structs, slices, and maps too.
 //
+// - h.EQWith is like h.EQ, but it can ignore fields, compare floats
+//   approximately, ignore the order of slices, etc.
+//
+//   	 PACKAGE.That(t, got, h.EQWith(want, h.IgnoreFields("CreatedAt")))
+//
+// - h.RegisterComparator defines the ordering of a user type for all tests. A
+//   h.ComparatorRegistry attached with Using applies only to one matcher, which
+//   is safe for parallel tests.
+//
+//   	 PACKAGE.That(t, got, h.EQ(want).Using(reg))
+//
 // - h.Contains checks if a slice or an array contains a given value or matcher.
 //
 //   	 PACKAGE.That(t, []int{15, 16}, h.Contains(15))
@@ -58,6 +110,19 @@
 //
 // - h.Each checks if every value of a slice or an array matches a given value or matcher.
 //
+// - h.UnorderedElementsAre, h.IsSupersetOf and h.IsSubsetOf match the elements
+//   of a slice or an array against values or matchers, in any order.
+//
+//   	 PACKAGE.That(t, []int{3, 1, 2}, h.IsSupersetOf(1, h.GT(2)))
+//
+// - h.Len and h.IsEmpty check the length of a slice, array, string, map, or
+//   channel.
+//
+// - h.MapContains, h.HasKey, h.HasKeys, h.MapKeysAre, h.MapValuesAre and
+//   h.MapEQ check the contents of a map.
+//
+//   	 PACKAGE.That(t, m, h.MapEQ(map[string]interface{}{"a": 1, "b": h.GT(1)}))
+//
 // - h.Not(m) negates the match result of m.
 //
 //   	 // Check that every element in the slice doesn't start with "b"
@@ -65,11 +130,63 @@
 //
 //
 // - h.Regexp, h.HasPrefix, h.HasSubstr, h.HasSuffix checks properties of a string.
+//   h.EqualFold, h.HasPrefixFold, h.HasSubstrFold and h.HasSuffixFold ignore
+//   case. h.Glob matches a shell pattern, h.EqualIgnoringWhitespace ignores
+//   spacing, and h.Lines matches the lines of a string.
+//
+//   	 PACKAGE.That(t, out, h.Lines(h.Contains(h.HasPrefix("ERROR"))))
+//
+// - h.StringEQ is like h.EQ for strings, but it shows where the strings differ.
+//
+// - h.Field and h.Property check a part of a struct.
+//
+//   	 PACKAGE.That(t, resp, h.Field("Status", h.EQ(200)))
+//
+// - h.JSONEq, h.YAMLEq and h.ProtoEq compare serialized payloads and protobuf
+//   messages semantically. h.JSONPath checks a part of a JSON document.
+//
+//   	 PACKAGE.That(t, body, h.JSONPath("$.items[0].id", "abc"))
+//
+// - h.ErrorIs, h.ErrorAs, h.ErrorMessage, h.ErrorChainContains and
+//   h.AWSErrorCode check errors, including the errors they wrap.
+//
+//   	 PACKAGE.That(t, err, h.AWSErrorCode("NoSuchKey"))
+//
+// - h.Eventually and h.Consistently poll a func() T; h.Receives and h.IsClosed
+//   check channels.
+//
+//   	 PACKAGE.That(t, counter.Get, h.Eventually(10, time.Second, time.Millisecond))
+//
+// - h.FileExists and h.FileContains check the file at a path.
+//
+// - h.NewMatcherBuilder creates custom matchers that compose like the built-in
+//   ones.
+//
+// Mocks of interfaces are generated by the command
+// github.com/grailbio/testutil/cmd/hmockgen. Their expected calls take h
+// matchers as arguments; see package github.com/grailbio/testutil/mock.
+//
+// Properties that must hold for many random inputs are checked with package
+// github.com/grailbio/testutil/prop, which reports the smallest failing input:
+//
+//   	 prop.ForAll(t, prop.SliceOf(prop.Int()), func(s []int) *h.Matcher {
+//   	   return h.EQ(reverse(reverse(s)))
+//   	 })
+//
+// Failures can also be collected in machine-readable form by installing a
+// reporter, e.g., h.SetReporter(h.NewJSONReporter(w)) or
+// h.SetReporter(h.NewJUnitReporter(w)).
 package PACKAGE
 
 // Generated from utils.go.tpl. DO NOT EDIT.
 
 import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
 	"github.com/grailbio/testutil/h"
 )
 
@@ -79,58 +196,89 @@ import (
 // If msgs... is not empty, msgs[0] must be a format string, and they are
 // printed using fmt.Printf on error.
 func EQ(t TB, got, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, got, h.EQ(want), msgs...)
 }
 
 // NEQ checks if want != got. If msgs... is not empty, msgs[0] must
 // be a format string, and they are printed using fmt.Printf on error.
 func NEQ(t TB, got, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, got, h.NEQ(want), msgs...)
 }
 
 // LE checks if x <= y. x and y must be of the same type and operator '<=' must
-// be defined for the type in Go. If msgs... is not empty, msgs[0] must be a
-// format string, and they are printed using fmt.Printf on error.
+// be defined for the type in Go, or they must both be numbers, which are
+// compared by value. If msgs... is not empty, msgs[0] must be a format
+// string, and they are printed using fmt.Printf on error.
 func LE(t TB, x, y interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, x, h.LE(y), msgs...)
 }
 
-// LT checks if x < y. x and y must be of the same type and operator '<' must be
-// defined for the type in Go. If msgs... is not empty, msgs[0] must be a format
+// LT checks if x < y. x and y must be of the same type and operator '<' must
+// be defined for the type in Go, or they must both be numbers, which are
+// compared by value. If msgs... is not empty, msgs[0] must be a format
 // string, and they are printed using fmt.Printf on error.
 func LT(t TB, x, y interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, x, h.LT(y), msgs...)
 }
 
 // GE checks if x >= y. x and y must be of the same type and operator '>=' must
-// be defined for the type in Go. If msgs... is not empty, msgs[0] must be a
-// format string, and they are printed using fmt.Printf on error.
+// be defined for the type in Go, or they must both be numbers, which are
+// compared by value. If msgs... is not empty, msgs[0] must be a format
+// string, and they are printed using fmt.Printf on error.
 func GE(t TB, x, y interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, x, h.GE(y), msgs...)
 }
 
-// GT checks if x > y. x and y must be of the same type and operator '>' must be
-// defined for the type in Go. If msgs... is not empty, msgs[0] must be a format
+// GT checks if x > y. x and y must be of the same type and operator '>' must
+// be defined for the type in Go, or they must both be numbers, which are
+// compared by value. If msgs... is not empty, msgs[0] must be a format
 // string, and they are printed using fmt.Printf on error.
 func GT(t TB, x, y interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, x, h.GT(y), msgs...)
 }
 
 // Nil checks if the value is nil. If msgs... is not empty, msgs[0] must
 // be a format string, and they are printed using fmt.Printf on error.
 func Nil(t TB, x interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, x, h.Nil(), msgs...)
 }
 
 // NotNil checks if the value is not nil. If msgs... is not empty, msgs[0] must
 // be a format string, and they are printed using fmt.Printf on error.
 func NotNil(t TB, x interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, x, h.Not(h.Nil()), msgs...)
 }
 
 // NoError is an alias of Nil. If msgs... is not empty, msgs[0] must
 // be a format string, and they are printed using fmt.Printf on error.
 func NoError(t TB, got error, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, got, h.NoError(), msgs...)
 }
 
@@ -140,6 +288,9 @@ func NoError(t TB, got error, msgs ...interface{}) {
 // regexp.Find. If msgs... is not empty, msgs[0] must be a format string, and
 // they are printed using fmt.Printf on error.
 func Regexp(t TB, got interface{}, re interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, got, h.Regexp(re), msgs...)
 }
 
@@ -148,6 +299,9 @@ func Regexp(t TB, got interface{}, re interface{}, msgs ...interface{}) {
 // msgs[0] must be a format string, and they are printed using fmt.Printf on
 // error.
 func HasSubstr(t TB, got interface{}, sub string, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, got, h.HasSubstr(sub), msgs...)
 }
 
@@ -156,17 +310,353 @@ func HasSubstr(t TB, got interface{}, sub string, msgs ...interface{}) {
 // empty, msgs[0] must be a format string, and they are printed using fmt.Printf
 // on error.
 func HasPrefix(t TB, got interface{}, prefix string, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	That(t, got, h.HasPrefix(prefix), msgs...)
 }
 
+// HasSuffix checks if the value "got" ends with "suffix". If "got" is not a
+// string, it is converted to string using fmt.Sprintf("%v"). If msgs... is not
+// empty, msgs[0] must be a format string, and they are printed using fmt.Printf
+// on error.
+func HasSuffix(t TB, got interface{}, suffix string, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.HasSuffix(suffix), msgs...)
+}
+
 // True checks if got==true. If msgs... is not empty, msgs[0] must
 // be a format string, and they are printed using fmt.Printf on error.
 func True(t TB, got bool, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	EQ(t, got, true, msgs...)
 }
 
 // False checks if got==false. If msgs... is not empty, msgs[0] must be a format
 // string, and they are printed using fmt.Printf on error.
 func False(t TB, got bool, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
 	EQ(t, got, false, msgs...)
 }
+
+// Contains checks if the slice, array or string "got" contains "want", which can
+// be a value or a matcher. It is a shorthand for That(got, h.Contains(want),
+// ...). If msgs... is not empty, msgs[0] must be a format string, and they are
+// printed using fmt.Printf on error.
+func Contains(t TB, got, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.Contains(want), msgs...)
+}
+
+// ElementsAre checks if the elements of the slice or array "got" match the
+// elements of "want", in order. "want" is a slice or an array of values or
+// matchers, e.g., []int{1, 2} or []interface{}{1, h.GT(1)}. If msgs... is not
+// empty, msgs[0] must be a format string, and they are printed using
+// fmt.Printf on error.
+func ElementsAre(t TB, got, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.ElementsAre(elements(want)...), msgs...)
+}
+
+// UnorderedElementsAre is like ElementsAre, but the elements can be in any
+// order. If msgs... is not empty, msgs[0] must be a format string, and they
+// are printed using fmt.Printf on error.
+func UnorderedElementsAre(t TB, got, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.UnorderedElementsAre(elements(want)...), msgs...)
+}
+
+// Each checks if every element of the slice or array "got" matches "want",
+// which can be a value or a matcher. If msgs... is not empty, msgs[0] must be
+// a format string, and they are printed using fmt.Printf on error.
+func Each(t TB, got, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.Each(want), msgs...)
+}
+
+// MapContains checks if the map "got" has an entry whose key and value match
+// "key" and "val", which can be values or matchers. If msgs... is not empty,
+// msgs[0] must be a format string, and they are printed using fmt.Printf on
+// error.
+func MapContains(t TB, got, key, val interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.MapContains(key, val), msgs...)
+}
+
+// Len checks if the length of the slice, array, string, map or channel "got"
+// matches "want", which can be an int or a matcher. If msgs... is not empty,
+// msgs[0] must be a format string, and they are printed using fmt.Printf on
+// error.
+func Len(t TB, got, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.Len(want), msgs...)
+}
+
+// Zero checks if "got" is the zero value of its type. If msgs... is not empty,
+// msgs[0] must be a format string, and they are printed using fmt.Printf on
+// error.
+func Zero(t TB, got interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.Zero(), msgs...)
+}
+
+// FloatNear checks if the number "got" is within maxDelta of "want". If
+// msgs... is not empty, msgs[0] must be a format string, and they are printed
+// using fmt.Printf on error.
+func FloatNear(t TB, got interface{}, want, maxDelta float64, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.FloatNear(want, maxDelta), msgs...)
+}
+
+// Panics checks if calling fn panics with a value that matches "want", which
+// can be a value or a matcher. Pass h.NotNil() to accept any panic. If msgs...
+// is not empty, msgs[0] must be a format string, and they are printed using
+// fmt.Printf on error.
+func Panics(t TB, fn func(), want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, fn, h.Panics(want), msgs...)
+}
+
+// ErrorIs checks if errors.Is(got, target). If msgs... is not empty, msgs[0]
+// must be a format string, and they are printed using fmt.Printf on error.
+func ErrorIs(t TB, got, target error, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.ErrorIs(target), msgs...)
+}
+
+// FileExists checks if a file or a directory exists at "path". If msgs... is
+// not empty, msgs[0] must be a format string, and they are printed using
+// fmt.Printf on error.
+func FileExists(t TB, path string, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, path, h.FileExists(), msgs...)
+}
+
+// FileContains checks if the file at "path" can be read, and its contents
+// match "want". If "want" is a string, the file must contain it as a
+// substring; otherwise it is a value or a matcher that is applied to the
+// contents as a string. If msgs... is not empty, msgs[0] must be a format
+// string, and they are printed using fmt.Printf on error.
+func FileContains(t TB, path string, want interface{}, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, path, h.FileContains(want), msgs...)
+}
+
+// ThatOf is a type-safe version of That. The matcher must check values of the
+// type of "got", so a type mistake is a compile error rather than a
+// DomainError. If msgs... is not empty, msgs[0] must be a format string, and
+// they are printed using fmt.Printf on error.
+func ThatOf[T any](t TB, got T, m h.Of[T], msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, m.Matcher(), msgs...)
+}
+
+// Equal is a type-safe version of EQ: "got" and "want" must be of the same
+// type. If msgs... is not empty, msgs[0] must be a format string, and they are
+// printed using fmt.Printf on error.
+func Equal[T any](t TB, got, want T, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.EQ(want), msgs...)
+}
+
+// NotEqual is a type-safe version of NEQ: "got" and "want" must be of the same
+// type. If msgs... is not empty, msgs[0] must be a format string, and they are
+// printed using fmt.Printf on error.
+func NotEqual[T any](t TB, got, want T, msgs ...interface{}) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	That(t, got, h.NEQ(want), msgs...)
+}
+
+// Case is a case of a table-driven test run by Table.
+type Case[In any] struct {
+	// Name names the subtest of the case.
+	Name string
+	// In is the input passed to the function under test.
+	In In
+	// Want is the expected output: a *h.Matcher, or a value compared with
+	// h.EQ.
+	Want interface{}
+}
+
+// TableTB is the part of *testing.T used by Table. T is the type of the test
+// itself, i.e., *testing.T in practice.
+type TableTB[T any] interface {
+	TB
+	Helper()
+	Name() string
+	Run(name string, f func(t T)) bool
+	Parallel()
+	Skip(args ...interface{})
+	Failed() bool
+	Cleanup(f func())
+	Log(args ...interface{})
+}
+
+// TableOption configures Table.
+type TableOption func(o *tableOptions)
+
+type tableOptions struct {
+	parallel    bool
+	focus, skip []string
+}
+
+// ParallelCases runs the cases of a table in parallel.
+func ParallelCases() TableOption {
+	return func(o *tableOptions) { o.parallel = true }
+}
+
+// FocusCases runs only the cases whose names match one of the patterns, in the
+// syntax of path.Match. The other cases are skipped.
+func FocusCases(patterns ...string) TableOption {
+	return func(o *tableOptions) { o.focus = append(o.focus, patterns...) }
+}
+
+// SkipCases skips the cases whose names match one of the patterns, in the
+// syntax of path.Match.
+func SkipCases(patterns ...string) TableOption {
+	return func(o *tableOptions) { o.skip = append(o.skip, patterns...) }
+}
+
+// matchCaseName checks if name matches one of the path.Match patterns.
+func matchCaseName(name string, patterns []string) bool {
+	for _, p := range patterns {
+		ok, err := path.Match(p, name)
+		if err != nil {
+			panic(fmt.Sprintf("PACKAGE.Table: bad pattern %q: %v", p, err))
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Table runs a table-driven test: for every case, it runs fn(c.In) in a
+// subtest named c.Name, and checks the output with c.Want. When the test
+// finishes, the failed cases are summarized in a table.
+//
+// Example:
+//   PACKAGE.Table(t, []PACKAGE.Case[string]{
+//   	{Name: "empty", In: "", Want: 0},
+//   	{Name: "word", In: "hello", Want: h.GT(0)},
+//   }, utf8.RuneCountInString, PACKAGE.ParallelCases())
+func Table[T TableTB[T], In, Out any](t T, cases []Case[In], fn func(in In) Out, opts ...TableOption) {
+	t.Helper()
+	var o tableOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// failures[i] is set if cases[i] failed: it holds the columns of the
+	// summary table.
+	failures := make([][]string, len(cases))
+	t.Cleanup(func() {
+		var rows [][]string
+		for _, f := range failures {
+			if f != nil {
+				rows = append(rows, f)
+			}
+		}
+		if len(rows) == 0 {
+			return
+		}
+		buf := strings.Builder{}
+		fmt.Fprintf(&buf, "%d of %d cases failed:\n", len(rows), len(cases))
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  CASE\tINPUT\tGOT\tWANT")
+		for _, row := range rows {
+			fmt.Fprintln(w, "  "+strings.Join(row, "\t"))
+		}
+		w.Flush()
+		t.Log(buf.String())
+	})
+	for i, c := range cases {
+		i, c := i, c
+		t.Run(c.Name, func(st T) {
+			st.Helper()
+			if len(o.focus) > 0 && !matchCaseName(c.Name, o.focus) {
+				st.Skip("not focused")
+			}
+			if matchCaseName(c.Name, o.skip) {
+				st.Skip("skipped")
+			}
+			if o.parallel {
+				st.Parallel()
+			}
+			m := h.As[Out](c.Want).Matcher()
+			got := fn(c.In)
+			// Record the failure in a defer, since assert.That stops the subtest.
+			defer func() {
+				if st.Failed() {
+					failures[i] = []string{c.Name, summarize(c.In), summarize(got), summarize(m.Msg)}
+				}
+			}()
+			That(st, got, m)
+		})
+	}
+}
+
+// summarize formats a value for the summary table of Table, on one short line.
+func summarize(v interface{}) string {
+	const max = 30
+	s := strings.Join(strings.Fields(fmt.Sprintf("%v", v)), " ")
+	if s == "" {
+		s = fmt.Sprintf("%q", s)
+	}
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max-3]) + "..."
+	}
+	return s
+}
+
+// elements converts a slice or an array to a []interface{}, for ElementsAre
+// and UnorderedElementsAre.
+func elements(want interface{}) []interface{} {
+	if w, ok := want.([]interface{}); ok {
+		return w
+	}
+	v := reflect.ValueOf(want)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic(fmt.Sprintf("PACKAGE: %v (type %T) must be a slice or an array", want, want))
+	}
+	elems := make([]interface{}, v.Len())
+	for i := range elems {
+		elems[i] = v.Index(i).Interface()
+	}
+	return elems
+}
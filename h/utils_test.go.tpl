@@ -3,9 +3,16 @@ package PACKAGE_test
 
 import (
 	"fmt"
-        "math"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
 
 	"github.com/grailbio/testutil/PACKAGE"
+	"github.com/grailbio/testutil/h"
 )
 
 type T struct{}
@@ -129,6 +136,13 @@ func ExampleHasPrefix() {
 	// Output:
 }
 
+func ExampleHasSuffix() {
+	t := &T{}
+	PACKAGE.HasSuffix(t, "12345", "345")
+	PACKAGE.HasSuffix(t, 12345, "345")
+	// Output:
+}
+
 func ExampleTrue() {
 	t := &T{}
 	PACKAGE.True(t, true)
@@ -166,3 +180,153 @@ func ExampleNotNil() {
 	PACKAGE.NotNil(t, map[int]int{})
 	// Output:
 }
+
+func ExampleContains() {
+	t := &T{}
+	PACKAGE.Contains(t, []int{10, 12}, 12)
+	PACKAGE.Contains(t, []string{"abc", "def"}, h.HasPrefix("d"))
+	// Output:
+}
+
+func ExampleElementsAre() {
+	t := &T{}
+	PACKAGE.ElementsAre(t, []int{10, 12}, []int{10, 12})
+	PACKAGE.ElementsAre(t, []int{10, 12}, []interface{}{10, h.GT(11)})
+	PACKAGE.UnorderedElementsAre(t, []int{10, 12}, []interface{}{12, h.LT(11)})
+	// Output:
+}
+
+func ExampleEach() {
+	t := &T{}
+	PACKAGE.Each(t, []int{10, 12}, h.GE(10))
+	PACKAGE.Len(t, []int{10, 12}, 2)
+	PACKAGE.Len(t, "abc", h.LT(4))
+	PACKAGE.MapContains(t, map[string]int{"a": 1}, "a", 1)
+	PACKAGE.Zero(t, struct{ x int }{})
+	PACKAGE.FloatNear(t, 0.1+0.2, 0.3, 1e-9)
+	// Output:
+}
+
+func ExamplePanics() {
+	t := &T{}
+	PACKAGE.Panics(t, func() { panic("fox jumped over a dog") }, h.HasSubstr("fox"))
+	PACKAGE.Panics(t, func() { panic(10) }, h.NotNil())
+	// Output:
+}
+
+func ExampleErrorIs() {
+	t := &T{}
+	PACKAGE.ErrorIs(t, fmt.Errorf("open: %w", os.ErrNotExist), os.ErrNotExist)
+	// Output:
+}
+
+func ExampleFileContains() {
+	t := &T{}
+	dir, err := ioutil.TempDir("", "")
+	PACKAGE.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.txt")
+	PACKAGE.NoError(t, ioutil.WriteFile(path, []byte("server started\nserver stopped\n"), 0600))
+	PACKAGE.FileExists(t, path)
+	PACKAGE.FileContains(t, path, "started")
+	PACKAGE.FileContains(t, path, h.Lines(h.Len(2)))
+	// Output:
+}
+
+func ExampleEqual() {
+	t := &T{}
+	PACKAGE.Equal(t, 42, 42)
+	PACKAGE.NotEqual(t, "abc", "abd")
+	PACKAGE.Equal(t, []int{1, 2}, []int{1, 2})
+	PACKAGE.ThatOf(t, 42, h.Less(50))
+	PACKAGE.ThatOf(t, "abc", h.As[string](h.HasPrefix("ab")))
+	// Output:
+}
+
+// tableT implements PACKAGE.TableTB for ExampleTable. It runs the subtests one
+// after the other, and prints their names when they fail.
+type tableT struct {
+	name     string
+	failed   bool
+	cleanups []func()
+}
+
+func (t *tableT) Error(args ...interface{}) {
+	if !t.failed {
+		fmt.Println(t.name, "failed")
+	}
+	t.failed = true
+}
+
+func (t *tableT) Fatal(args ...interface{}) {
+	t.Error(args...)
+	runtime.Goexit()
+}
+
+func (t *tableT) Helper()                 {}
+func (t *tableT) Name() string            { return t.name }
+func (t *tableT) Parallel()               {}
+func (t *tableT) Failed() bool            { return t.failed }
+func (t *tableT) Cleanup(f func())        { t.cleanups = append(t.cleanups, f) }
+func (t *tableT) Log(args ...interface{}) { fmt.Print(args...) }
+
+func (t *tableT) Skip(args ...interface{}) {
+	fmt.Println(t.name, "skipped")
+	runtime.Goexit()
+}
+
+func (t *tableT) Run(name string, f func(t *tableT)) bool {
+	st := &tableT{name: t.name + "/" + name}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(st)
+	}()
+	<-done
+	t.failed = t.failed || st.failed
+	return !st.failed
+}
+
+func (t *tableT) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func ExampleTable() {
+	t := &tableT{name: "TestLen"}
+	PACKAGE.Table(t, []PACKAGE.Case[string]{
+		{Name: "empty", In: "", Want: 0},
+		{Name: "word", In: "hello", Want: h.LT(3)},
+		{Name: "long", In: strings.Repeat("ab", 20), Want: 0},
+		{Name: "flaky", In: "x", Want: 2},
+	}, func(s string) int { return len(s) }, PACKAGE.SkipCases("fl*"))
+	t.finish()
+	// Output:
+	// TestLen/word failed
+	// TestLen/long failed
+	// TestLen/flaky skipped
+	// 2 of 4 cases failed:
+	//   CASE  INPUT                           GOT  WANT
+	//   word  hello                           5    is < (int)3
+	//   long  abababababababababababababa...  40   (int)0
+}
+
+func TestTable(t *testing.T) {
+	cases := []PACKAGE.Case[int]{
+		{Name: "zero", In: 0, Want: 0},
+		{Name: "positive", In: 3, Want: h.GT(0)},
+		{Name: "negative", In: -3, Want: -1},
+	}
+	sign := func(x int) int {
+		switch {
+		case x < 0:
+			return -1
+		case x > 0:
+			return 1
+		}
+		return 0
+	}
+	PACKAGE.Table(t, cases, sign, PACKAGE.ParallelCases())
+	PACKAGE.Table(t, cases, func(x int) int { return x }, PACKAGE.FocusCases("zero", "pos*"))
+}
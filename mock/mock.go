@@ -0,0 +1,437 @@
+// Package mock is the runtime of the mocks generated by cmd/hmockgen. The
+// arguments of the expected calls are specified with the matchers of package
+// h, in the style of gmock:
+//
+//   ctrl := mock.NewController(t)
+//   store := NewMockStore(ctrl)
+//   store.EXPECT().Get(h.HasPrefix("user/"), h.Any()).Return([]byte("alice"), nil).Times(2)
+//   store.EXPECT().Put("user/1", h.Not(h.Nil())).Return(nil).AtLeast(1)
+//
+// The expectations are verified when the test finishes, and the unmet ones
+// are reported through package expect.
+package mock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func init() {
+	// The failures are reported at the test, rather than inside Controller.
+	h.RegisterInternalPackage(reflect.TypeOf((*Controller)(nil)).Elem().PkgPath())
+}
+
+// Controller records the expected calls of a set of mocks, and checks the
+// actual calls against them. It is safe for concurrent use.
+type Controller struct {
+	t expect.TB
+
+	mu       sync.Mutex
+	calls    []*Call // in the order they were registered.
+	finished bool
+}
+
+// NewController creates a controller that reports failures to t. If t supports
+// Cleanup, as *testing.T does, Finish is called when the test finishes.
+// Otherwise, the test must call Finish.
+func NewController(t expect.TB) *Controller {
+	c := &Controller{t: t}
+	if tc, ok := t.(interface{ Cleanup(func()) }); ok {
+		tc.Cleanup(c.Finish)
+	}
+	return c
+}
+
+// Call is an expected call of a mock method. Its methods set the cardinality,
+// the ordering and the action of the call, and return the call for chaining.
+type Call struct {
+	receiver   interface{}
+	method     string
+	methodType reflect.Type // without the receiver.
+	args       []*h.Matcher
+
+	minCalls, maxCalls int // maxCalls < 0 means unlimited.
+	prereqs            []*Call
+	sideEffects        []func(args []interface{})             // set by Do.
+	action             func(args []interface{}) []interface{} // set by Return or DoAndReturn.
+
+	calls int // guarded by Controller.mu.
+}
+
+// Expect registers an expected call. It is called by the generated mocks. args
+// are the expected arguments, which are immediate values or *h.Matchers; a nil
+// value matches a nil argument of any type. By default, the call is expected
+// exactly once, and returns zero values.
+func (c *Controller) Expect(receiver interface{}, method string, args ...interface{}) *Call {
+	m, ok := reflect.TypeOf(receiver).MethodByName(method)
+	if !ok {
+		panic(fmt.Sprintf("mock: %T has no method %s", receiver, method))
+	}
+	methodType := m.Type
+	// Drop the receiver.
+	in := make([]reflect.Type, methodType.NumIn()-1)
+	for i := range in {
+		in[i] = methodType.In(i + 1)
+	}
+	out := make([]reflect.Type, methodType.NumOut())
+	for i := range out {
+		out[i] = methodType.Out(i)
+	}
+	call := &Call{
+		receiver:   receiver,
+		method:     method,
+		methodType: reflect.FuncOf(in, out, methodType.IsVariadic()),
+		minCalls:   1,
+		maxCalls:   1,
+	}
+	if len(args) != len(in) {
+		panic(fmt.Sprintf("mock: %s: got %d arguments, want %d", call.name(), len(args), len(in)))
+	}
+	for _, a := range args {
+		switch a := a.(type) {
+		case *h.Matcher:
+			call.args = append(call.args, a)
+		case nil:
+			call.args = append(call.args, h.Nil())
+		default:
+			call.args = append(call.args, h.EQ(a))
+		}
+	}
+	c.mu.Lock()
+	c.calls = append(c.calls, call)
+	c.mu.Unlock()
+	return call
+}
+
+// name returns the name of the method of the call, e.g., "MockStore.Get".
+func (call *Call) name() string {
+	return methodName(call.receiver, call.method)
+}
+
+// methodName returns the name of a method of a mock, e.g., "MockStore.Get".
+func methodName(receiver interface{}, method string) string {
+	typ := reflect.TypeOf(receiver)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Name() + "." + method
+}
+
+// String describes the call, e.g., `MockStore.Get(has prefix "a", any)`.
+func (call *Call) String() string {
+	descs := make([]string, len(call.args))
+	for i, a := range call.args {
+		descs[i] = a.Msg
+	}
+	return fmt.Sprintf("%s(%s)", call.name(), strings.Join(descs, ", "))
+}
+
+// Times sets the number of times the call is expected.
+func (call *Call) Times(n int) *Call {
+	call.minCalls, call.maxCalls = n, n
+	return call
+}
+
+// AtLeast sets the minimum number of times the call is expected, without an
+// upper limit.
+func (call *Call) AtLeast(n int) *Call {
+	call.minCalls, call.maxCalls = n, -1
+	return call
+}
+
+// AtMost sets the maximum number of times the call is expected, without a
+// lower limit.
+func (call *Call) AtMost(n int) *Call {
+	call.minCalls, call.maxCalls = 0, n
+	return call
+}
+
+// AnyTimes allows the call any number of times, including zero.
+func (call *Call) AnyTimes() *Call {
+	call.minCalls, call.maxCalls = 0, -1
+	return call
+}
+
+// After makes the call match only after the calls in prereqs have been made
+// their minimum number of times.
+func (call *Call) After(prereqs ...*Call) *Call {
+	call.prereqs = append(call.prereqs, prereqs...)
+	return call
+}
+
+// InOrder declares that the calls must happen in the given order.
+func InOrder(calls ...*Call) {
+	for i := 1; i < len(calls); i++ {
+		calls[i].After(calls[i-1])
+	}
+}
+
+// Return sets the values returned by the call. They must be assignable to the
+// result types of the method; nil stands for the zero value.
+func (call *Call) Return(values ...interface{}) *Call {
+	typ := call.methodType
+	if len(values) != typ.NumOut() {
+		panic(fmt.Sprintf("mock: %s.Return: got %d values, want %d", call.name(), len(values), typ.NumOut()))
+	}
+	ret := make([]interface{}, len(values))
+	for i, v := range values {
+		out := typ.Out(i)
+		if v == nil {
+			ret[i] = reflect.Zero(out).Interface()
+			continue
+		}
+		vt := reflect.TypeOf(v)
+		switch {
+		case vt.AssignableTo(out):
+		case vt.ConvertibleTo(out) && isNumber(vt) && isNumber(out):
+			// Allows untyped constants, e.g., Return(1) for an int64.
+			v = reflect.ValueOf(v).Convert(out).Interface()
+		default:
+			panic(fmt.Sprintf("mock: %s.Return: value #%d %v (type %v) is not assignable to %v", call.name(), i, v, vt, out))
+		}
+		ret[i] = v
+	}
+	call.action = func([]interface{}) []interface{} { return ret }
+	return call
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// DoAndReturn makes the call invoke fn with the arguments, and return its
+// results. fn must have the signature of the method.
+func (call *Call) DoAndReturn(fn interface{}) *Call {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || fv.Type() != call.methodType {
+		panic(fmt.Sprintf("mock: %s.DoAndReturn: %T must be of type %v", call.name(), fn, call.methodType))
+	}
+	call.action = func(args []interface{}) []interface{} {
+		results := callFunc(fv, args)
+		ret := make([]interface{}, len(results))
+		for i, r := range results {
+			ret[i] = r.Interface()
+		}
+		return ret
+	}
+	return call
+}
+
+// Do makes the call invoke fn with the arguments, and return zero values, or
+// the values set by Return. fn must take the arguments of the method, and its
+// results are ignored.
+func (call *Call) Do(fn interface{}) *Call {
+	fv := reflect.ValueOf(fn)
+	typ := call.methodType
+	ok := fv.Kind() == reflect.Func && fv.Type().NumIn() == typ.NumIn() && fv.Type().IsVariadic() == typ.IsVariadic()
+	for i := 0; ok && i < typ.NumIn(); i++ {
+		ok = typ.In(i).AssignableTo(fv.Type().In(i))
+	}
+	if !ok {
+		panic(fmt.Sprintf("mock: %s.Do: %T must take the arguments %v", call.name(), fn, call.methodType))
+	}
+	call.sideEffects = append(call.sideEffects, func(args []interface{}) { callFunc(fv, args) })
+	return call
+}
+
+// callFunc calls fv with the arguments of a mock method. A variadic argument is
+// passed as a slice.
+func callFunc(fv reflect.Value, args []interface{}) []reflect.Value {
+	in := make([]reflect.Value, len(args))
+	for i, a := range args {
+		if a == nil {
+			in[i] = reflect.Zero(fv.Type().In(i))
+		} else {
+			in[i] = reflect.ValueOf(a)
+		}
+	}
+	if fv.Type().IsVariadic() {
+		return fv.CallSlice(in)
+	}
+	return fv.Call(in)
+}
+
+// exhausted checks if the call has been made its maximum number of times.
+func (call *Call) exhausted() bool {
+	return call.maxCalls >= 0 && call.calls >= call.maxCalls
+}
+
+// satisfied checks if the call has been made its minimum number of times.
+func (call *Call) satisfied() bool {
+	return call.calls >= call.minCalls
+}
+
+// match checks args against the expected arguments. It returns the result of
+// the first argument that doesn't match, or a Match result.
+func (call *Call) match(args []interface{}) (int, h.Result) {
+	for i, a := range call.args {
+		if r := a.Match(args[i]); r.Status() != h.Match {
+			return i, r
+		}
+	}
+	return -1, h.NewResult(true, nil, "")
+}
+
+// Call records a call of a mock method, and returns the results of the
+// matching expected call, one per result of the method. It is called by the
+// generated mocks. An unexpected call is reported through expect.That, at the
+// caller of the mock, and returns zero values.
+func (c *Controller) Call(receiver interface{}, method string, args ...interface{}) []interface{} {
+	c.mu.Lock()
+	var (
+		found    *Call
+		mismatch []string
+	)
+	for _, call := range c.calls {
+		if call.receiver != receiver || call.method != method {
+			continue
+		}
+		i, r := call.match(args)
+		switch {
+		case i >= 0:
+			mismatch = append(mismatch, fmt.Sprintf("%s: argument #%d doesn't match\n%s", call, i, describeMismatch(args[i], r)))
+			continue
+		case call.exhausted():
+			mismatch = append(mismatch, fmt.Sprintf("%s: called too many times (at most %d)", call, call.maxCalls))
+			continue
+		}
+		if pending := call.pendingPrereqs(); len(pending) > 0 {
+			mismatch = append(mismatch, fmt.Sprintf("%s: must happen after %s", call, strings.Join(pending, ", ")))
+			continue
+		}
+		found = call
+		break
+	}
+	if found != nil {
+		found.calls++
+	}
+	c.mu.Unlock()
+
+	if found == nil {
+		typ := reflect.TypeOf(receiver)
+		m, ok := typ.MethodByName(method)
+		if !ok {
+			panic(fmt.Sprintf("mock: %T has no method %s", receiver, method))
+		}
+		c.reportUnexpected(methodName(receiver, method), args, mismatch)
+		ret := make([]interface{}, m.Type.NumOut())
+		for i := range ret {
+			ret[i] = reflect.Zero(m.Type.Out(i)).Interface()
+		}
+		return ret
+	}
+	for _, f := range found.sideEffects {
+		f(args)
+	}
+	var ret []interface{}
+	if found.action != nil {
+		ret = found.action(args)
+	}
+	if ret == nil {
+		ret = make([]interface{}, found.methodType.NumOut())
+		for i := range ret {
+			ret[i] = reflect.Zero(found.methodType.Out(i)).Interface()
+		}
+	}
+	return ret
+}
+
+// pendingPrereqs lists the prerequisites of the call that aren't satisfied
+// yet.
+func (call *Call) pendingPrereqs() []string {
+	var pending []string
+	for _, p := range call.prereqs {
+		if !p.satisfied() {
+			pending = append(pending, p.String())
+		}
+	}
+	return pending
+}
+
+// reportUnexpected reports an unexpected call of the method name through
+// expect.That. mismatch describes the expected calls of the method that don't
+// match.
+func (c *Controller) reportUnexpected(name string, args []interface{}, mismatch []string) {
+	m := &h.Matcher{
+		Msg:    fmt.Sprintf("match an expected call of %s", name),
+		NotMsg: fmt.Sprintf("don't match any expected call of %s", name),
+	}
+	m.Match = func(got interface{}) h.Result { return h.NewResult(false, got, m.Msg) }
+	msg := fmt.Sprintf("unexpected call %s(%s)", name, describeArgs(args))
+	if len(mismatch) > 0 {
+		msg += "\n\nExpected calls that don't match:\n" + strings.Join(mismatch, "\n")
+	}
+	// Report the failure at the caller of the generated method.
+	expect.That(expect.WithCallerSkip(c.t, 1), append([]interface{}{}, args...), m, "%s", msg)
+}
+
+// describeMismatch describes the result r of matching the argument arg, like
+// h.Result.String but without the failure site, which is inside the mock.
+func describeMismatch(arg interface{}, r h.Result) string {
+	label := "Expected:"
+	if r.Status() == h.DomainError {
+		label = "Error:   "
+	}
+	return fmt.Sprintf("  Actual:   %s\n  %s %s", describeArgs([]interface{}{arg}), label, r.Message())
+}
+
+// describeArgs describes the arguments of a call like package h describes
+// values, e.g., "(string)b".
+func describeArgs(args []interface{}) string {
+	descs := make([]string, len(args))
+	for i, a := range args {
+		if a == nil {
+			descs[i] = "nil"
+			continue
+		}
+		descs[i] = spew.Sprintf("%#v", a)
+	}
+	return strings.Join(descs, ", ")
+}
+
+// cardinality returns a matcher for the number of calls of call.
+func (call *Call) cardinality() *h.Matcher {
+	switch {
+	case call.maxCalls < 0:
+		return h.GE(call.minCalls)
+	case call.minCalls == call.maxCalls:
+		return h.EQ(call.minCalls)
+	}
+	return h.InRange(call.minCalls, call.maxCalls, true)
+}
+
+// Finish verifies that every expected call has been made the expected number
+// of times, and reports the others through expect.That. Calls after the first
+// do nothing.
+func (c *Controller) Finish() {
+	if th, ok := c.t.(interface{ Helper() }); ok {
+		th.Helper()
+	}
+	c.mu.Lock()
+	if c.finished {
+		c.mu.Unlock()
+		return
+	}
+	c.finished = true
+	calls := append([]*Call(nil), c.calls...)
+	counts := make([]int, len(calls))
+	for i, call := range calls {
+		counts[i] = call.calls
+	}
+	c.mu.Unlock()
+	for i, call := range calls {
+		expect.That(c.t, counts[i], call.cardinality(), "number of calls of %s", call)
+	}
+}
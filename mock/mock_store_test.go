@@ -0,0 +1,90 @@
+// Code generated by hmockgen. DO NOT EDIT.
+
+package mock_test
+
+import (
+	"context"
+
+	"github.com/grailbio/testutil/mock"
+)
+
+// MockStore is a mock of the Store interface.
+type MockStore struct {
+	ctrl *mock.Controller
+}
+
+// NewMockStore creates a mock of Store that records the calls in ctrl.
+func NewMockStore(ctrl *mock.Controller) *MockStore {
+	return &MockStore{ctrl: ctrl}
+}
+
+// MockStoreRecorder registers the expected calls of a MockStore.
+type MockStoreRecorder struct {
+	mock *MockStore
+}
+
+// EXPECT returns an object that registers the expected calls.
+func (m *MockStore) EXPECT() *MockStoreRecorder {
+	return &MockStoreRecorder{mock: m}
+}
+
+// Close implements Store.Close.
+func (m *MockStore) Close() error {
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close registers an expected call of Close.
+func (r *MockStoreRecorder) Close() *mock.Call {
+	return r.mock.ctrl.Expect(r.mock, "Close")
+}
+
+// Get implements Store.Get.
+func (m *MockStore) Get(ctx context.Context, key string) ([]byte, error) {
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get registers an expected call of Get. The arguments are values or h matchers.
+func (r *MockStoreRecorder) Get(ctx, key interface{}) *mock.Call {
+	return r.mock.ctrl.Expect(r.mock, "Get", ctx, key)
+}
+
+// Len implements Store.Len.
+func (m *MockStore) Len() int {
+	ret := m.ctrl.Call(m, "Len")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Len registers an expected call of Len.
+func (r *MockStoreRecorder) Len() *mock.Call {
+	return r.mock.ctrl.Expect(r.mock, "Len")
+}
+
+// List implements Store.List.
+func (m *MockStore) List(prefix string, opts ...Option) []string {
+	ret := m.ctrl.Call(m, "List", prefix, opts)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// List registers an expected call of List. The arguments are values or h matchers.
+func (r *MockStoreRecorder) List(prefix, opts interface{}) *mock.Call {
+	return r.mock.ctrl.Expect(r.mock, "List", prefix, opts)
+}
+
+// Put implements Store.Put.
+func (m *MockStore) Put(ctx context.Context, key string, value []byte) error {
+	ret := m.ctrl.Call(m, "Put", ctx, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put registers an expected call of Put. The arguments are values or h matchers.
+func (r *MockStoreRecorder) Put(ctx, key, value interface{}) *mock.Call {
+	return r.mock.ctrl.Expect(r.mock, "Put", ctx, key, value)
+}
@@ -0,0 +1,145 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+	"github.com/grailbio/testutil/mock"
+)
+
+// tester records the errors reported by a Controller.
+type tester struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (t *tester) Error(args ...interface{}) {
+	t.mu.Lock()
+	t.errors = append(t.errors, args[0].(string))
+	t.mu.Unlock()
+}
+
+// cleanupTester is a tester that runs the cleanup functions on demand.
+type cleanupTester struct {
+	tester
+	cleanups []func()
+}
+
+func (t *cleanupTester) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *cleanupTester) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestMockReturn(t *testing.T) {
+	ctx := context.Background()
+	ctrl := mock.NewController(t)
+	store := NewMockStore(ctrl)
+	store.EXPECT().Get(h.Any(), h.HasPrefix("user/")).Return([]byte("alice"), nil).Times(2)
+	store.EXPECT().Get(h.Any(), "missing").Return(nil, errors.New("not found"))
+	store.EXPECT().Len().Return(3).AnyTimes()
+	store.EXPECT().Close()
+
+	for i := 0; i < 2; i++ {
+		data, err := store.Get(ctx, "user/1")
+		expect.NoError(t, err)
+		expect.EQ(t, string(data), "alice")
+	}
+	_, err := store.Get(ctx, "missing")
+	expect.That(t, err, h.ErrorMessage("not found"))
+	expect.EQ(t, store.Len(), 3)
+	expect.NoError(t, store.Close())
+}
+
+func TestMockActions(t *testing.T) {
+	ctrl := mock.NewController(t)
+	store := NewMockStore(ctrl)
+	var puts []string
+	store.EXPECT().Put(h.Any(), h.Any(), h.Len(h.GT(0))).Do(func(_ context.Context, key string, _ []byte) {
+		puts = append(puts, key)
+	}).AtLeast(1)
+	store.EXPECT().List("a/", h.ElementsAre(Option("recursive"))).DoAndReturn(func(prefix string, opts ...Option) []string {
+		return []string{prefix + "x", prefix + "y"}
+	})
+	expect.NoError(t, store.Put(context.Background(), "k1", []byte("v")))
+	expect.NoError(t, store.Put(context.Background(), "k2", []byte("v")))
+	expect.EQ(t, puts, []string{"k1", "k2"})
+	expect.EQ(t, store.List("a/", "recursive"), []string{"a/x", "a/y"})
+}
+
+func TestMockOrder(t *testing.T) {
+	tt := &tester{}
+	ctrl := mock.NewController(tt)
+	store := NewMockStore(ctrl)
+	mock.InOrder(
+		store.EXPECT().Put(h.Any(), "k", h.Any()),
+		store.EXPECT().Close(),
+	)
+	store.Close() // Too early.
+	expect.That(t, tt.errors, h.ElementsAre(h.AllOf(
+		h.Regexp(`mock_test.go:\d+: store.Close\(\) // Too early.\n`),
+		h.HasSubstr("\nExpected: match an expected call of MockStore.Close\n  unexpected call MockStore.Close()\n"),
+		h.HasSuffix("\nMockStore.Close(): must happen after MockStore.Put(any, (string)k, any)"))))
+
+	expect.NoError(t, store.Put(context.Background(), "k", nil))
+	expect.NoError(t, store.Close())
+	ctrl.Finish()
+	expect.EQ(t, len(tt.errors), 1)
+}
+
+func TestMockUnexpectedCall(t *testing.T) {
+	tt := &tester{}
+	ctrl := mock.NewController(tt)
+	store := NewMockStore(ctrl)
+	store.EXPECT().Get(h.Any(), "a").Return([]byte("x"), nil)
+	data, err := store.Get(context.Background(), "b")
+	expect.Nil(t, data)
+	expect.NoError(t, err)
+	expect.That(t, tt.errors, h.ElementsAre(h.AllOf(
+		h.HasSubstr(`: data, err := store.Get(context.Background(), "b")`+"\n"),
+		h.Regexp(`\n  unexpected call MockStore\.Get\(.*, \(string\)b\)\n`),
+		h.HasSuffix("\nMockStore.Get(any, (string)a): argument #1 doesn't match\n  Actual:   (string)b\n  Expected: (string)a"))))
+}
+
+func TestMockFinish(t *testing.T) {
+	tt := &tester{}
+	ctrl := mock.NewController(tt)
+	store := NewMockStore(ctrl)
+	store.EXPECT().Len().Return(1).Times(2)
+	store.EXPECT().Close().Return(nil).AtMost(1)
+	store.EXPECT().Get(h.Any(), h.Any()).AtLeast(1)
+	store.Len()
+	store.Close()
+	store.Close() // Exhausted.
+	ctrl.Finish()
+	ctrl.Finish()
+	expect.That(t, tt.errors, h.ElementsAre(
+		h.HasSubstr("\n  unexpected call MockStore.Close()\n"),
+		h.AllOf(h.HasSubstr("Actual:   (int)1\nExpected: (int)2"), h.HasSubstr("number of calls of MockStore.Len()")),
+		h.AllOf(h.HasSubstr("Actual:   (int)0\nExpected: >= (int)1"), h.HasSubstr("number of calls of MockStore.Get(any, any)"))))
+}
+
+func TestMockFinishAtCleanup(t *testing.T) {
+	tt := &cleanupTester{}
+	ctrl := mock.NewController(expect.WithCallerSkip(tt, 1))
+	store := NewMockStore(ctrl)
+	store.EXPECT().Len()
+	tt.finish()
+	expect.That(t, tt.errors, h.ElementsAre(h.HasSubstr("number of calls of MockStore.Len()")))
+}
+
+func TestMockPanics(t *testing.T) {
+	ctrl := mock.NewController(&tester{})
+	store := NewMockStore(ctrl)
+	expect.That(t, func() { store.EXPECT().Len().Return("x") }, h.Panics(h.HasSubstr("is not assignable to int")))
+	expect.That(t, func() { store.EXPECT().Len().Return(1, 2) }, h.Panics(h.HasSubstr("got 2 values, want 1")))
+	expect.That(t, func() { store.EXPECT().Len().DoAndReturn(func() string { return "" }) },
+		h.Panics(h.HasSubstr("must be of type func() int")))
+	expect.That(t, func() { ctrl.Expect(store, "Nope") }, h.Panics(h.HasSubstr("has no method Nope")))
+}
@@ -0,0 +1,20 @@
+package mock_test
+
+import (
+	"context"
+	"io"
+)
+
+//go:generate go run ../cmd/hmockgen -source store_test.go -interfaces Store -out mock_store_test.go
+
+// Store is the interface mocked by the tests.
+type Store interface {
+	io.Closer
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	List(prefix string, opts ...Option) []string
+	Len() int
+}
+
+// Option is an option of Store.List.
+type Option string
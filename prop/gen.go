@@ -0,0 +1,534 @@
+package prop
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+)
+
+// Gen generates random values of type T, and shrinks them. Create one with the
+// functions of this package, or with New.
+type Gen[T any] struct {
+	generate func(r *rand.Rand, size int) T
+	shrink   func(x T) []T
+}
+
+// New creates a generator. generate returns a random value; size, which grows
+// during a ForAll run, bounds the magnitude of numbers and the length of
+// collections. shrink returns simpler variants of a value, simplest first; it
+// may be nil if the values can't be shrunk.
+func New[T any](generate func(r *rand.Rand, size int) T, shrink func(x T) []T) Gen[T] {
+	if shrink == nil {
+		shrink = func(T) []T { return nil }
+	}
+	return Gen[T]{generate, shrink}
+}
+
+// Generate returns a random value.
+func (g Gen[T]) Generate(r *rand.Rand, size int) T { return g.generate(r, size) }
+
+// Shrink returns simpler variants of x, simplest first.
+func (g Gen[T]) Shrink(x T) []T { return g.shrink(x) }
+
+// valueGen is a generator of reflect.Values of one type. The generators built
+// by reflection are composed from valueGens.
+type valueGen struct {
+	generate func(r *rand.Rand, size int) reflect.Value
+	shrink   func(v reflect.Value) []reflect.Value
+}
+
+// typed converts a valueGen to a Gen[T].
+func typed[T any](vg valueGen) Gen[T] {
+	return Gen[T]{
+		generate: func(r *rand.Rand, size int) T { return vg.generate(r, size).Interface().(T) },
+		shrink: func(x T) []T {
+			var result []T
+			for _, v := range vg.shrink(reflect.ValueOf(&x).Elem()) {
+				result = append(result, v.Interface().(T))
+			}
+			return result
+		},
+	}
+}
+
+// untyped converts a Gen[T] to a valueGen.
+func untyped[T any](g Gen[T]) valueGen {
+	return valueGen{
+		generate: func(r *rand.Rand, size int) reflect.Value {
+			x := g.generate(r, size)
+			return reflect.ValueOf(&x).Elem()
+		},
+		shrink: func(v reflect.Value) []reflect.Value {
+			var result []reflect.Value
+			for _, x := range g.shrink(v.Interface().(T)) {
+				x := x
+				result = append(result, reflect.ValueOf(&x).Elem())
+			}
+			return result
+		},
+	}
+}
+
+// Any creates a generator of arbitrary values of type T, built by reflection.
+// T may be a bool, a number, a string, or a slice, an array, a map, a pointer
+// or a struct of those. Unexported struct fields are left zero. It panics for
+// the other types, e.g., channels, functions and interfaces, and for recursive
+// types, such as a struct with a pointer to its own type.
+//
+// Example:
+//   type point struct{ X, Y int }
+//   prop.ForAll(t, prop.Any[[]point](), func(ps []point) *h.Matcher { ... })
+func Any[T any]() Gen[T] {
+	return typed[T](genFor(reflect.TypeOf((*T)(nil)).Elem(), map[reflect.Type]bool{}))
+}
+
+// genFor creates a valueGen for typ. inProgress holds the types whose
+// valueGens are being created, which contain typ.
+func genFor(typ reflect.Type, inProgress map[reflect.Type]bool) valueGen {
+	if inProgress[typ] {
+		panic(fmt.Sprintf("prop: can't generate values of recursive type %v", typ))
+	}
+	inProgress[typ] = true
+	defer delete(inProgress, typ)
+	switch typ.Kind() {
+	case reflect.Bool:
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				return reflect.ValueOf(r.Intn(2) == 1).Convert(typ)
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				if v.Bool() {
+					return []reflect.Value{reflect.Zero(typ)}
+				}
+				return nil
+			},
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := typ.Bits()
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				n := r.Int63n(int64(size)*2+1) - int64(size)
+				max := int64(1)<<(bits-1) - 1
+				if n > max || n < -max-1 {
+					n %= max
+				}
+				return reflect.ValueOf(n).Convert(typ)
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				var result []reflect.Value
+				for _, n := range shrinkInt(v.Int()) {
+					result = append(result, reflect.ValueOf(n).Convert(typ))
+				}
+				return result
+			},
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		bits := typ.Bits()
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				n := uint64(r.Int63n(int64(size) + 1))
+				if bits < 64 {
+					n %= uint64(1) << bits
+				}
+				return reflect.ValueOf(n).Convert(typ)
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				var result []reflect.Value
+				for _, n := range shrinkUint(v.Uint()) {
+					result = append(result, reflect.ValueOf(n).Convert(typ))
+				}
+				return result
+			},
+		}
+	case reflect.Float32, reflect.Float64:
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				return reflect.ValueOf((r.Float64()*2 - 1) * float64(size)).Convert(typ)
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				var result []reflect.Value
+				for _, f := range shrinkFloat(v.Float()) {
+					result = append(result, reflect.ValueOf(f).Convert(typ))
+				}
+				return result
+			},
+		}
+	case reflect.String:
+		// Strings shrink by removing runes; simpler runes than random ones
+		// would rarely be simpler to read.
+		runes := sliceGen(reflect.TypeOf([]rune(nil)), valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value { return reflect.ValueOf(randomRune(r)) },
+			shrink:   noShrink,
+		})
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				return reflect.ValueOf(string(runes.generate(r, size).Interface().([]rune))).Convert(typ)
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				var result []reflect.Value
+				for _, rs := range runes.shrink(reflect.ValueOf([]rune(v.String()))) {
+					result = append(result, reflect.ValueOf(string(rs.Interface().([]rune))).Convert(typ))
+				}
+				return result
+			},
+		}
+	case reflect.Slice:
+		return sliceGen(typ, genFor(typ.Elem(), inProgress))
+	case reflect.Array:
+		elem := genFor(typ.Elem(), inProgress)
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				v := reflect.New(typ).Elem()
+				for i := 0; i < v.Len(); i++ {
+					v.Index(i).Set(elem.generate(r, size))
+				}
+				return v
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				var result []reflect.Value
+				for i := 0; i < v.Len(); i++ {
+					for _, e := range elem.shrink(v.Index(i)) {
+						c := reflect.New(typ).Elem()
+						reflect.Copy(c, v)
+						c.Index(i).Set(e)
+						result = append(result, c)
+					}
+				}
+				return result
+			},
+		}
+	case reflect.Map:
+		return mapGen(typ, genFor(typ.Key(), inProgress), genFor(typ.Elem(), inProgress))
+	case reflect.Ptr:
+		elem := genFor(typ.Elem(), inProgress)
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				if r.Intn(10) == 0 {
+					return reflect.Zero(typ)
+				}
+				p := reflect.New(typ.Elem())
+				p.Elem().Set(elem.generate(r, size))
+				return p
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				if v.IsNil() {
+					return nil
+				}
+				result := []reflect.Value{reflect.Zero(typ)}
+				for _, e := range elem.shrink(v.Elem()) {
+					p := reflect.New(typ.Elem())
+					p.Elem().Set(e)
+					result = append(result, p)
+				}
+				return result
+			},
+		}
+	case reflect.Struct:
+		fields := make([]*valueGen, typ.NumField())
+		for i := range fields {
+			if f := typ.Field(i); f.PkgPath == "" { // Exported.
+				fg := genFor(f.Type, inProgress)
+				fields[i] = &fg
+			}
+		}
+		return valueGen{
+			generate: func(r *rand.Rand, size int) reflect.Value {
+				v := reflect.New(typ).Elem()
+				for i, fg := range fields {
+					if fg != nil {
+						v.Field(i).Set(fg.generate(r, size))
+					}
+				}
+				return v
+			},
+			shrink: func(v reflect.Value) []reflect.Value {
+				var result []reflect.Value
+				for i, fg := range fields {
+					if fg == nil {
+						continue
+					}
+					for _, f := range fg.shrink(v.Field(i)) {
+						c := reflect.New(typ).Elem()
+						c.Set(v)
+						c.Field(i).Set(f)
+						result = append(result, c)
+					}
+				}
+				return result
+			},
+		}
+	}
+	panic(fmt.Sprintf("prop: can't generate values of type %v", typ))
+}
+
+// noShrink is the shrink function of the values that aren't shrunk.
+func noShrink(reflect.Value) []reflect.Value { return nil }
+
+// randomRune returns a printable ASCII character most of the time, and an
+// arbitrary valid rune otherwise.
+func randomRune(r *rand.Rand) rune {
+	if r.Intn(4) > 0 {
+		return rune(' ' + r.Intn('~'-' '+1))
+	}
+	for {
+		c := rune(r.Intn(utf8.MaxRune + 1))
+		if utf8.ValidRune(c) {
+			return c
+		}
+	}
+}
+
+// sliceGen creates a valueGen for slices of type typ, whose elements are
+// generated by elem.
+func sliceGen(typ reflect.Type, elem valueGen) valueGen {
+	return valueGen{
+		generate: func(r *rand.Rand, size int) reflect.Value {
+			n := r.Intn(size + 1)
+			v := reflect.MakeSlice(typ, n, n)
+			for i := 0; i < n; i++ {
+				v.Index(i).Set(elem.generate(r, size))
+			}
+			return v
+		},
+		shrink: func(v reflect.Value) []reflect.Value {
+			n := v.Len()
+			if n == 0 {
+				return nil
+			}
+			without := func(start, end int) reflect.Value {
+				c := reflect.MakeSlice(typ, 0, n-(end-start))
+				c = reflect.AppendSlice(c, v.Slice(0, start))
+				return reflect.AppendSlice(c, v.Slice(end, n))
+			}
+			// Remove large chunks first, then single elements, then shrink the
+			// elements in place.
+			result := []reflect.Value{reflect.MakeSlice(typ, 0, 0)}
+			for chunk := n / 2; chunk > 1; chunk /= 2 {
+				for start := 0; start+chunk <= n; start += chunk {
+					result = append(result, without(start, start+chunk))
+				}
+			}
+			for i := 0; n > 1 && i < n; i++ {
+				result = append(result, without(i, i+1))
+			}
+			for i := 0; i < n; i++ {
+				for _, e := range elem.shrink(v.Index(i)) {
+					c := reflect.MakeSlice(typ, n, n)
+					reflect.Copy(c, v)
+					c.Index(i).Set(e)
+					result = append(result, c)
+				}
+			}
+			return result
+		},
+	}
+}
+
+// mapGen creates a valueGen for maps of type typ.
+func mapGen(typ reflect.Type, key, val valueGen) valueGen {
+	return valueGen{
+		generate: func(r *rand.Rand, size int) reflect.Value {
+			n := r.Intn(size + 1)
+			v := reflect.MakeMapWithSize(typ, n)
+			for i := 0; i < n; i++ {
+				v.SetMapIndex(key.generate(r, size), val.generate(r, size))
+			}
+			return v
+		},
+		shrink: func(v reflect.Value) []reflect.Value {
+			if v.Len() == 0 {
+				return nil
+			}
+			keys := v.MapKeys()
+			// Sort the keys, so that shrinking is deterministic.
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+			})
+			clone := func() reflect.Value {
+				c := reflect.MakeMapWithSize(typ, v.Len())
+				for _, k := range keys {
+					c.SetMapIndex(k, v.MapIndex(k))
+				}
+				return c
+			}
+			result := []reflect.Value{reflect.MakeMap(typ)}
+			for _, k := range keys {
+				c := clone()
+				c.SetMapIndex(k, reflect.Value{})
+				result = append(result, c)
+			}
+			for _, k := range keys {
+				for _, e := range val.shrink(v.MapIndex(k)) {
+					c := clone()
+					c.SetMapIndex(k, e)
+					result = append(result, c)
+				}
+			}
+			return result
+		},
+	}
+}
+
+// shrinkInt returns simpler integers than n, moving toward 0.
+func shrinkInt(n int64) []int64 {
+	if n == 0 {
+		return nil
+	}
+	result := []int64{0}
+	if half := n / 2; half != 0 {
+		result = append(result, half)
+	}
+	if n < 0 && n != math.MinInt64 {
+		result = append(result, -n)
+	}
+	if next := n - n/absInt64(n); next != 0 && next != n/2 {
+		result = append(result, next)
+	}
+	return result
+}
+
+func absInt64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// shrinkUint returns simpler integers than n, moving toward 0.
+func shrinkUint(n uint64) []uint64 {
+	if n == 0 {
+		return nil
+	}
+	result := []uint64{0}
+	if half := n / 2; half != 0 {
+		result = append(result, half)
+	}
+	if n-1 != 0 && n-1 != n/2 {
+		result = append(result, n-1)
+	}
+	return result
+}
+
+// shrinkFloat returns simpler floats than f, moving toward 0 and integers.
+func shrinkFloat(f float64) []float64 {
+	if f == 0 || math.IsNaN(f) {
+		return nil
+	}
+	result := []float64{0}
+	if t := math.Trunc(f); t != f && t != 0 && !math.IsInf(f, 0) {
+		result = append(result, t)
+	}
+	if half := f / 2; math.Abs(half) >= 1e-6 && !math.IsInf(f, 0) {
+		result = append(result, half)
+	}
+	if f < 0 {
+		result = append(result, -f)
+	}
+	return result
+}
+
+// Int creates a generator of ints in [-size, size], which shrink toward 0.
+func Int() Gen[int] { return Any[int]() }
+
+// IntRange creates a generator of ints in [lo, hi], which shrink toward lo.
+func IntRange(lo, hi int) Gen[int] {
+	if lo > hi {
+		panic(fmt.Sprintf("prop.IntRange: lo %d > hi %d", lo, hi))
+	}
+	return New(
+		func(r *rand.Rand, size int) int { return lo + int(r.Int63n(int64(hi)-int64(lo)+1)) },
+		func(x int) []int {
+			var result []int
+			for _, d := range shrinkUint(uint64(x - lo)) {
+				result = append(result, lo+int(d))
+			}
+			return result
+		})
+}
+
+// Float64 creates a generator of float64s in [-size, size), which shrink
+// toward 0.
+func Float64() Gen[float64] { return Any[float64]() }
+
+// Bool creates a generator of bools, which shrink toward false.
+func Bool() Gen[bool] { return Any[bool]() }
+
+// String creates a generator of strings of at most size runes, mostly
+// printable ASCII characters. They shrink by removing runes.
+func String() Gen[string] { return Any[string]() }
+
+// Bytes creates a generator of byte slices of at most size bytes. They shrink
+// by removing bytes, and toward zero bytes.
+func Bytes() Gen[[]byte] { return Any[[]byte]() }
+
+// BytesFunc creates a generator of byte slices of at most size bytes, whose
+// contents are produced by fn. It adapts the data generators used with
+// package encryptiontest, e.g., a function that returns n pseudo-random or
+// ascending bytes. The slices shrink by removing bytes.
+func BytesFunc(fn func(nbytes int) []byte) Gen[[]byte] {
+	// The bytes themselves are not shrunk, since fn determines their contents.
+	bytes := typed[[]byte](sliceGen(reflect.TypeOf([]byte(nil)), valueGen{shrink: noShrink}))
+	return New(
+		func(r *rand.Rand, size int) []byte { return fn(r.Intn(size + 1)) },
+		bytes.shrink)
+}
+
+// SliceOf creates a generator of slices of at most size elements generated by
+// elem.
+func SliceOf[T any](elem Gen[T]) Gen[[]T] {
+	return typed[[]T](sliceGen(reflect.TypeOf([]T(nil)), untyped(elem)))
+}
+
+// MapOf creates a generator of maps of at most size entries, whose keys and
+// values are generated by key and val.
+func MapOf[K comparable, V any](key Gen[K], val Gen[V]) Gen[map[K]V] {
+	return typed[map[K]V](mapGen(reflect.TypeOf(map[K]V(nil)), untyped(key), untyped(val)))
+}
+
+// OneOf creates a generator that picks one of values. The values shrink toward
+// the first one.
+func OneOf[T any](values ...T) Gen[T] {
+	if len(values) == 0 {
+		panic("prop.OneOf: no values")
+	}
+	return New(
+		func(r *rand.Rand, size int) T { return values[r.Intn(len(values))] },
+		func(x T) []T {
+			if reflect.DeepEqual(x, values[0]) {
+				return nil
+			}
+			return values[:1]
+		})
+}
+
+// Map creates a generator that applies fn to the values generated by g. The
+// results don't shrink, since fn can't be inverted.
+func Map[T, U any](g Gen[T], fn func(x T) U) Gen[U] {
+	return New(func(r *rand.Rand, size int) U { return fn(g.generate(r, size)) }, nil)
+}
+
+// Filter creates a generator of the values of g that satisfy pred. It panics
+// if pred rejects 1000 values in a row.
+func Filter[T any](g Gen[T], pred func(x T) bool) Gen[T] {
+	return New(
+		func(r *rand.Rand, size int) T {
+			for i := 0; i < 1000; i++ {
+				if x := g.generate(r, size); pred(x) {
+					return x
+				}
+			}
+			panic("prop.Filter: too many values rejected")
+		},
+		func(x T) []T {
+			var result []T
+			for _, s := range g.shrink(x) {
+				if pred(s) {
+					result = append(result, s)
+				}
+			}
+			return result
+		})
+}
@@ -0,0 +1,119 @@
+package prop_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+	"github.com/grailbio/testutil/prop"
+)
+
+type point struct {
+	X, Y   int8
+	Label  string
+	hidden int
+}
+
+type shape struct {
+	Points []point
+	Tags   map[string]bool
+	Parent *shape2
+}
+
+type shape2 struct {
+	Name string
+}
+
+// node is a recursive type.
+type node struct {
+	Value int
+	Next  *node
+}
+
+func TestAny(t *testing.T) {
+	gen := prop.Any[shape]()
+	r := rand.New(rand.NewSource(1))
+	var sawPoints, sawTags, sawParent bool
+	for i := 0; i < 100; i++ {
+		s := gen.Generate(r, 10)
+		expect.LE(t, len(s.Points), 10)
+		for _, p := range s.Points {
+			expect.That(t, int(p.X), h.InRange(-10, 10, true))
+			expect.LE(t, len([]rune(p.Label)), 10)
+			expect.EQ(t, p.hidden, 0)
+		}
+		sawPoints = sawPoints || len(s.Points) > 0
+		sawTags = sawTags || len(s.Tags) > 0
+		sawParent = sawParent || s.Parent != nil
+	}
+	expect.True(t, sawPoints)
+	expect.True(t, sawTags)
+	expect.True(t, sawParent)
+
+	// The same seed generates the same values.
+	a := gen.Generate(rand.New(rand.NewSource(42)), 50)
+	b := gen.Generate(rand.New(rand.NewSource(42)), 50)
+	expect.EQ(t, a, b)
+
+	expect.That(t, func() { prop.Any[chan int]() }, h.Panics(h.HasSubstr("can't generate values of type chan int")))
+	expect.That(t, func() { prop.Any[[]node]() }, h.Panics(h.HasSubstr("can't generate values of recursive type prop_test.node")))
+}
+
+func TestShrink(t *testing.T) {
+	expect.That(t, prop.Int().Shrink(10), h.ElementsAre(0, 5, 9))
+	expect.That(t, prop.Int().Shrink(-3), h.ElementsAre(0, -1, 3, -2))
+	expect.That(t, prop.Int().Shrink(0), h.IsEmpty())
+	expect.That(t, prop.IntRange(5, 10).Shrink(9), h.ElementsAre(5, 7, 8))
+	expect.That(t, prop.Float64().Shrink(2.5), h.ElementsAre(0.0, 2.0, 1.25))
+	expect.That(t, prop.Bool().Shrink(true), h.ElementsAre(false))
+	expect.That(t, prop.String().Shrink("ab"), h.ElementsAre("", "b", "a"))
+	expect.That(t, prop.Any[*int]().Shrink(nil), h.IsEmpty())
+
+	shrunk := prop.SliceOf(prop.Int()).Shrink([]int{1, 2, 3, 4})
+	expect.That(t, shrunk[:7], h.ElementsAre(
+		[]int{}, []int{3, 4}, []int{1, 2}, []int{2, 3, 4}, []int{1, 3, 4}, []int{1, 2, 4}, []int{1, 2, 3}))
+	expect.That(t, shrunk, h.Contains([]int{0, 2, 3, 4}))
+
+	shrunkMap := prop.MapOf(prop.String(), prop.Int()).Shrink(map[string]int{"a": 1, "b": 2})
+	expect.That(t, shrunkMap, h.ElementsAre(
+		map[string]int{}, map[string]int{"b": 2}, map[string]int{"a": 1},
+		map[string]int{"a": 0, "b": 2}, map[string]int{"a": 1, "b": 0}, map[string]int{"a": 1, "b": 1}))
+
+	shrunkPoints := prop.Any[point]().Shrink(point{X: 2, Label: "a"})
+	expect.That(t, shrunkPoints, h.ElementsAre(
+		point{X: 0, Label: "a"}, point{X: 1, Label: "a"}, point{X: 2}))
+}
+
+func TestGenerators(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		expect.That(t, prop.IntRange(-3, 3).Generate(r, 100), h.InRange(-3, 3, true))
+		expect.That(t, prop.OneOf("a", "b").Generate(r, 100), h.AnyOf("a", "b"))
+		expect.That(t, prop.Filter(prop.Int(), func(x int) bool { return x%2 == 0 }).Generate(r, 100)%2, h.EQ(0))
+		expect.That(t, len(prop.Map(prop.Int(), func(x int) []int { return make([]int, x*x) }).Generate(r, 3)), h.LE(9))
+	}
+	expect.That(t, prop.OneOf("a", "b").Shrink("b"), h.ElementsAre("a"))
+	expect.That(t, func() { prop.IntRange(2, 1) }, h.Panics(h.HasSubstr("lo 2 > hi 1")))
+}
+
+// ascendingBytes is a data generator in the style of package encryptiontest.
+func ascendingBytes(n int) []byte {
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	return buf
+}
+
+func TestBytesFunc(t *testing.T) {
+	gen := prop.BytesFunc(ascendingBytes)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		b := gen.Generate(r, 20)
+		expect.LE(t, len(b), 20)
+		expect.EQ(t, b, ascendingBytes(len(b)))
+	}
+	// The bytes are removed, but not changed.
+	expect.That(t, gen.Shrink([]byte{0, 1}), h.ElementsAre([]byte{}, []byte{1}, []byte{0}))
+}
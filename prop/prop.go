@@ -0,0 +1,188 @@
+// Package prop implements property-based testing with the matchers of package
+// github.com/grailbio/testutil/h. A property is checked against many random
+// inputs; when it fails, the input is shrunk to a simpler one that still
+// fails, and the failure is reported like expect.That, along with the seed
+// that reproduces it.
+//
+// Example:
+//   prop.ForAll(t, prop.SliceOf(prop.Int()), func(s []int) *h.Matcher {
+//     return h.EQ(reverse(reverse(s)))
+//   })
+//
+// A failing run prints the seed. To rerun the same inputs, set the environment
+// variable PROP_SEED, e.g., PROP_SEED=42 go test -run TestSort.
+package prop
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+// SeedEnv is the environment variable that overrides the random seed of the
+// runs whose Config.Seed is zero.
+const SeedEnv = "PROP_SEED"
+
+// Config configures a property check. The zero value uses the defaults.
+type Config struct {
+	// Seed seeds the random generator. If zero, the seed is read from the
+	// environment variable PROP_SEED, or derived from the current time.
+	Seed int64
+	// Runs is the number of random inputs to check. Defaults to 100.
+	Runs int
+	// MaxSize is the size passed to the generator on the last run; the size
+	// grows linearly from 1 on the first run. Defaults to 100.
+	MaxSize int
+	// MaxShrinks is the maximum number of times the property is checked while
+	// shrinking a failing input. Defaults to 1000.
+	MaxShrinks int
+}
+
+// withDefaults returns c with the zero fields set to their defaults.
+func (c Config) withDefaults() (Config, error) {
+	if c.Seed == 0 {
+		if s := os.Getenv(SeedEnv); s != "" {
+			seed, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return c, fmt.Errorf("prop: invalid %s=%q: %v", SeedEnv, s, err)
+			}
+			c.Seed = seed
+		} else {
+			c.Seed = time.Now().UnixNano()
+		}
+	}
+	if c.Runs <= 0 {
+		c.Runs = 100
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = 100
+	}
+	if c.MaxShrinks <= 0 {
+		c.MaxShrinks = 1000
+	}
+	return c, nil
+}
+
+func init() {
+	// The failures are reported at the property, rather than inside ForAll.
+	h.RegisterInternalPackage(reflect.TypeOf(Config{}).PkgPath())
+}
+
+// helper is implemented by *testing.T and *testing.B.
+type helper interface {
+	Helper()
+}
+
+// ForAll checks that the values generated by gen satisfy the matchers returned
+// by prop. prop receives the value, and returns the matcher to apply to it.
+//
+// Example:
+//   prop.ForAll(t, prop.String(), func(s string) *h.Matcher {
+//     return h.HasPrefix(s[:len(s)/2])
+//   })
+func ForAll[T any](t expect.TB, gen Gen[T], prop func(x T) *h.Matcher) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	ForAllWith(t, Config{}, gen, prop)
+}
+
+// ForAllWith is ForAll with an explicit configuration.
+func ForAllWith[T any](t expect.TB, c Config, gen Gen[T], prop func(x T) *h.Matcher) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	CheckWith(t, c, gen, func(x T) h.Result { return prop(x).Match(x) })
+}
+
+// Check checks that prop returns a matching result for the values generated by
+// gen. It is the lower-level form of ForAll, for properties that match
+// something other than the input, e.g.:
+//
+//   prop.Check(t, prop.Bytes(), func(b []byte) h.Result {
+//     return h.EQ(b).Match(decode(encode(b)))
+//   })
+func Check[T any](t expect.TB, gen Gen[T], prop func(x T) h.Result) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	CheckWith(t, Config{}, gen, prop)
+}
+
+// CheckWith is Check with an explicit configuration.
+func CheckWith[T any](t expect.TB, c Config, gen Gen[T], prop func(x T) h.Result) {
+	if th, ok := t.(helper); ok {
+		th.Helper()
+	}
+	c, err := c.withDefaults()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	rnd := rand.New(rand.NewSource(c.Seed))
+	for run := 0; run < c.Runs; run++ {
+		size := 1
+		if c.Runs > 1 {
+			size += run * (c.MaxSize - 1) / (c.Runs - 1)
+		}
+		x := gen.generate(rnd, size)
+		r := check(prop, x)
+		if r.Status() == h.Match {
+			continue
+		}
+		shrunk, r, steps := shrink(c, gen, prop, x, r)
+		heading := fmt.Sprintf("Property failed on run %d of %d (seed %d; rerun with %s=%d)\n",
+			run+1, c.Runs, c.Seed, SeedEnv, c.Seed)
+		if steps > 0 {
+			heading += fmt.Sprintf("Original: %s\nShrunk in %d steps to: %s\n",
+				spew.Sprintf("%#v", x), steps, spew.Sprintf("%#v", shrunk))
+		}
+		h.ReportFailure(t, r, strings.TrimSpace(heading))
+		t.Error(heading + r.String())
+		return
+	}
+}
+
+// check runs the property on x. A panic of the property is reported as a
+// DomainError.
+func check[T any](prop func(x T) h.Result, x T) (r h.Result) {
+	defer func() {
+		if p := recover(); p != nil {
+			r = h.NewErrorf(x, "property panicked: %v", p)
+		}
+	}()
+	return prop(x)
+}
+
+// shrink repeatedly replaces the failing input x by the first of its shrunk
+// variants that still fails, until none does or c.MaxShrinks checks were run.
+// It returns the simplest failing input, its result, and the number of times
+// it was shrunk.
+func shrink[T any](c Config, gen Gen[T], prop func(x T) h.Result, x T, r h.Result) (T, h.Result, int) {
+	steps, checks := 0, 0
+	for {
+		shrunk := false
+		for _, s := range gen.shrink(x) {
+			if checks >= c.MaxShrinks {
+				return x, r, steps
+			}
+			checks++
+			if sr := check(prop, s); sr.Status() != h.Match {
+				x, r, shrunk = s, sr, true
+				steps++
+				break
+			}
+		}
+		if !shrunk {
+			return x, r, steps
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package prop_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+	"github.com/grailbio/testutil/prop"
+)
+
+type tester struct {
+	msgs []string
+}
+
+func (t *tester) Error(args ...interface{}) {
+	t.msgs = append(t.msgs, fmt.Sprint(args...))
+}
+
+func reverse(s []int) []int {
+	r := make([]int, len(s))
+	for i, x := range s {
+		r[len(s)-1-i] = x
+	}
+	return r
+}
+
+func TestForAll(t *testing.T) {
+	prop.ForAll(t, prop.SliceOf(prop.Int()), func(s []int) *h.Matcher {
+		return h.EQ(reverse(reverse(s)))
+	})
+	prop.Check(t, prop.Any[map[string][]byte](), func(m map[string][]byte) h.Result {
+		return h.Len(len(m)).Match(m)
+	})
+}
+
+func TestForAllShrinks(t *testing.T) {
+	tt := &tester{}
+	prop.ForAllWith(tt, prop.Config{Seed: 1}, prop.Int(), func(x int) *h.Matcher {
+		return h.LT(10)
+	})
+	expect.That(t, tt.msgs, h.ElementsAre(h.Regexp(
+		`^Property failed on run \d+ of 100 \(seed 1; rerun with PROP_SEED=1\)\n`+
+			`Original: \(int\)\d+\nShrunk in \d+ steps to: \(int\)10\n`+
+			`.*prop_test.go:\d+: prop.ForAllWith\(.*\n`+
+			`Actual:   \(int\)10\nExpected: is < \(int\)10\n`)))
+
+	tt = &tester{}
+	prop.ForAllWith(tt, prop.Config{Seed: 1}, prop.SliceOf(prop.String()), func(s []string) *h.Matcher {
+		return h.Len(h.LT(3))
+	})
+	expect.That(t, tt.msgs, h.ElementsAre(h.HasSubstr(`Shrunk in`)))
+	expect.That(t, tt.msgs[0], h.HasSubstr(`Actual:   ([]string)[  ]`))
+
+	// The failure is reported only once.
+	tt = &tester{}
+	prop.ForAll(tt, prop.Bool(), func(b bool) *h.Matcher { return h.NEQ(b) })
+	expect.That(t, tt.msgs, h.ElementsAre(h.HasSubstr("Expected: is != (bool)false")))
+}
+
+func TestForAllSeed(t *testing.T) {
+	run := func(c prop.Config) []string {
+		tt := &tester{}
+		prop.ForAllWith(tt, c, prop.SliceOf(prop.Int()), func(s []int) *h.Matcher {
+			return h.Not(h.Contains(7))
+		})
+		// Keep only the heading, since the backtraces differ.
+		var headings []string
+		for _, msg := range tt.msgs {
+			if lines := strings.Split(msg, "\n"); len(lines) > 3 {
+				msg = strings.Join(lines[:3], "\n")
+			}
+			headings = append(headings, msg)
+		}
+		return headings
+	}
+	first := run(prop.Config{Seed: 42})
+	expect.That(t, first, h.ElementsAre(h.HasSubstr("seed 42")))
+	expect.EQ(t, run(prop.Config{Seed: 42}), first)
+
+	setenv := func(value string) {
+		old, ok := os.LookupEnv(prop.SeedEnv)
+		expect.NoError(t, os.Setenv(prop.SeedEnv, value))
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(prop.SeedEnv, old)
+			} else {
+				os.Unsetenv(prop.SeedEnv)
+			}
+		})
+	}
+	setenv("42")
+	expect.EQ(t, run(prop.Config{}), first)
+	setenv("x")
+	expect.That(t, run(prop.Config{}), h.ElementsAre(h.HasSubstr(`invalid PROP_SEED="x"`)))
+}
+
+func TestCheckPanic(t *testing.T) {
+	tt := &tester{}
+	prop.CheckWith(tt, prop.Config{Seed: 1}, prop.IntRange(0, 100), func(x int) h.Result {
+		if x > 20 {
+			panic(fmt.Sprintf("%d is too large", x))
+		}
+		return h.EQ(x).Match(x)
+	})
+	expect.That(t, tt.msgs, h.ElementsAre(h.AllOf(
+		h.HasSubstr("Shrunk in"),
+		h.HasSubstr("Actual:   (int)21\nError:    property panicked: 21 is too large"))))
+}
+
+func TestBytesFuncProperty(t *testing.T) {
+	tt := &tester{}
+	prop.ForAllWith(tt, prop.Config{Seed: 1}, prop.BytesFunc(ascendingBytes), func(b []byte) *h.Matcher {
+		return h.Not(h.Contains(byte(5)))
+	})
+	// The shortest input that contains 5 is [5].
+	expect.That(t, tt.msgs, h.ElementsAre(h.HasSubstr("Shrunk in")))
+	expect.That(t, tt.msgs[0], h.HasSubstr("Actual:   ([]uint8)[5]"))
+}
@@ -0,0 +1,440 @@
+package testutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/grailbio/testutil/h"
+)
+
+// FileComparator compares the contents of a file, got, with those of its
+// golden version, want. It returns "" if they are equivalent, or a description
+// of the differences. name is the path of the golden file.
+type FileComparator func(name string, got, want []byte) string
+
+// TextComparator compares files as text, after applying the normalizers in
+// order, and describes the differences with a unified diff.
+func TextComparator(normalizers ...Normalizer) FileComparator {
+	normalize := Normalize(normalizers...)
+	return func(name string, got, want []byte) string {
+		return h.UnifiedDiff("got", name, normalize(string(got)), normalize(string(want)))
+	}
+}
+
+// JSONComparator compares JSON files semantically, like Golden.JSON: the order
+// of object keys and the formatting don't matter.
+func JSONComparator(normalizers ...Normalizer) FileComparator {
+	normalize := Normalize(normalizers...)
+	return func(name string, got, want []byte) string {
+		d, err := diffJSON(name, got, want, normalize)
+		if err != nil {
+			return err.Error()
+		}
+		return d
+	}
+}
+
+// BinaryComparator compares files byte by byte, and describes the first
+// difference with a hex dump.
+func BinaryComparator() FileComparator {
+	return func(name string, got, want []byte) string { return diffBinary(got, want) }
+}
+
+// GzipComparator decompresses gzip files, and compares their contents with
+// inner.
+//
+// Example:
+//   opts := testutil.TreeOptions{Comparators: map[string]testutil.FileComparator{
+//     ".gz": testutil.GzipComparator(testutil.TextComparator()),
+//   }}
+func GzipComparator(inner FileComparator) FileComparator {
+	return func(name string, got, want []byte) string {
+		gotData, err := gunzip(got)
+		if err != nil {
+			return fmt.Sprintf("output is not valid gzip: %v", err)
+		}
+		wantData, err := gunzip(want)
+		if err != nil {
+			return fmt.Sprintf("%s is not valid gzip: %v", name, err)
+		}
+		return inner(name, gotData, wantData)
+	}
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// TreeOptions configures CompareTrees.
+type TreeOptions struct {
+	// Comparators maps file extensions, such as ".json", to the comparators of
+	// the files with that extension.
+	Comparators map[string]FileComparator
+	// Default compares the files whose extension is not in Comparators. It
+	// defaults to TextComparator().
+	Default FileComparator
+	// Perm compares the permission bits of the files and directories.
+	Perm bool
+	// Symlinks compares symbolic links by their targets. By default, they are
+	// followed and compared like the files they point to, except for the links
+	// that can't be followed, which are compared by their targets.
+	Symlinks bool
+	// ModTime compares the modification times of the regular files.
+	ModTime bool
+	// Ignore lists the relative paths to skip, as path.Match patterns, e.g.,
+	// "logs/*.log". A directory that matches is skipped with its contents.
+	// The ignored golden files are left alone when the golden tree is updated.
+	Ignore []string
+}
+
+// comparator returns the comparator of the file at path.
+func (o *TreeOptions) comparator(path string) FileComparator {
+	if c, ok := o.Comparators[filepath.Ext(path)]; ok {
+		return c
+	}
+	if o.Default != nil {
+		return o.Default
+	}
+	return TextComparator()
+}
+
+// ignored checks if the relative path matches one of o.Ignore.
+func (o *TreeOptions) ignored(rel string) bool {
+	for _, pattern := range o.Ignore {
+		if ok, err := path.Match(pattern, filepath.ToSlash(rel)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// treeEntries lists the paths under root, relative to root, with their file
+// info. Symbolic links are followed, unless o.Symlinks is set, but the
+// directories they point to are not listed. The links that can't be followed
+// are listed as links in entries, and with the error in broken.
+func treeEntries(t testing.TB, root string, o *TreeOptions) (entries map[string]os.FileInfo, broken map[string]error) {
+	t.Helper()
+	entries, broken = map[string]os.FileInfo{}, map[string]error{}
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if o.ignored(rel) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Mode()&os.ModeSymlink != 0 && !o.Symlinks {
+			if target, err := os.Stat(path); err == nil {
+				fi = target
+			} else {
+				broken[rel] = err
+			}
+		}
+		entries[rel] = fi
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("CompareTrees: %v", err)
+	}
+	return entries, broken
+}
+
+// fileKind describes the type of a file.
+func fileKind(fi os.FileInfo) string {
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		return "a symlink"
+	case fi.IsDir():
+		return "a directory"
+	case fi.Mode().IsRegular():
+		return "a file"
+	}
+	return "a special file"
+}
+
+// CompareTrees compares the directory tree got against the golden tree
+// golden. It reports the missing, extra and differing files in one error. The
+// contents of the files are compared by the comparators of opts, chosen by
+// file extension. When the golden files are being updated (see
+// UpdatingGolden), golden is replaced by a copy of got instead, except for the
+// ignored paths, which are kept.
+//
+// Example:
+//   testutil.CompareTrees(t, outDir, "testdata/golden/out", testutil.TreeOptions{
+//     Comparators: map[string]testutil.FileComparator{
+//       ".json": testutil.JSONComparator(),
+//       ".gz":   testutil.GzipComparator(testutil.TextComparator()),
+//     },
+//     Perm: true,
+//   })
+func CompareTrees(t testing.TB, got, golden string, opts TreeOptions) {
+	t.Helper()
+	if UpdatingGolden() {
+		if _, err := removeTree(golden, ".", &opts); err != nil {
+			t.Fatalf("CompareTrees: %v", err)
+		}
+		if err := copyTree(got, golden, &opts); err != nil {
+			t.Fatalf("CompareTrees: %v", err)
+		}
+		t.Logf("updated golden tree %s", golden)
+		return
+	}
+	gotEntries, gotBroken := treeEntries(t, got, &opts)
+	wantEntries, wantBroken := treeEntries(t, golden, &opts)
+	var paths []string
+	for rel := range gotEntries {
+		paths = append(paths, rel)
+	}
+	for rel := range wantEntries {
+		if _, ok := gotEntries[rel]; !ok {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	// mismatched holds the directories that are files in the other tree. Their
+	// contents are not reported.
+	mismatched := map[string]bool{}
+	for _, rel := range paths {
+		if underAny(rel, mismatched) {
+			continue
+		}
+		g, inGot := gotEntries[rel]
+		w, inGolden := wantEntries[rel]
+		switch {
+		case !inGot:
+			report("missing: %s", rel)
+			continue
+		case !inGolden:
+			report("extra: %s", rel)
+			continue
+		}
+		// The links that can't be followed are compared as links only if they
+		// are broken in both trees.
+		if gErr, wErr := gotBroken[rel], wantBroken[rel]; (gErr == nil) != (wErr == nil) {
+			if gErr == nil {
+				gErr = wErr
+			}
+			report("%s: cannot follow symlink: %v", rel, gErr)
+			continue
+		}
+		if gk, wk := fileKind(g), fileKind(w); gk != wk {
+			report("%s: got %s, want %s", rel, gk, wk)
+			if g.IsDir() || w.IsDir() {
+				mismatched[rel] = true
+			}
+			continue
+		}
+		gotPath, wantPath := filepath.Join(got, rel), filepath.Join(golden, rel)
+		if opts.Perm && g.Mode().Perm() != w.Mode().Perm() {
+			report("%s: got permissions %v, want %v", rel, g.Mode().Perm(), w.Mode().Perm())
+		}
+		if opts.ModTime && g.Mode().IsRegular() && !g.ModTime().Equal(w.ModTime()) {
+			report("%s: got modification time %v, want %v", rel, g.ModTime(), w.ModTime())
+		}
+		switch {
+		case g.Mode()&os.ModeSymlink != 0:
+			gt, err := os.Readlink(gotPath)
+			if err != nil {
+				t.Fatalf("CompareTrees: %v", err)
+			}
+			wt, err := os.Readlink(wantPath)
+			if err != nil {
+				t.Fatalf("CompareTrees: %v", err)
+			}
+			if gt != wt {
+				report("%s: got symlink to %s, want %s", rel, gt, wt)
+			}
+		case g.Mode().IsRegular():
+			gotData, err := ioutil.ReadFile(gotPath)
+			if err != nil {
+				t.Fatalf("CompareTrees: %v", err)
+			}
+			wantData, err := ioutil.ReadFile(wantPath)
+			if err != nil {
+				t.Fatalf("CompareTrees: %v", err)
+			}
+			if d := opts.comparator(rel)(wantPath, gotData, wantData); d != "" {
+				report("differs: %s\n%s", rel, indent(strings.TrimSuffix(d, "\n")))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		t.Errorf("tree %s doesn't match golden tree %s; rerun with %s=1 to update it:\n%s",
+			got, golden, UpdateGoldenEnv, strings.Join(problems, "\n"))
+	}
+}
+
+// removeTree removes the path rel under root, except for the paths that
+// o.Ignore matches, which are kept along with the directories that hold them.
+// It reports whether rel was removed entirely.
+func removeTree(root, rel string, o *TreeOptions) (bool, error) {
+	if rel != "." && o.ignored(rel) {
+		return false, nil
+	}
+	path := filepath.Join(root, rel)
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !fi.IsDir() {
+		return true, os.Remove(path)
+	}
+	names, err := readDirNames(path)
+	if err != nil {
+		return false, err
+	}
+	removed := true
+	for _, name := range names {
+		ok, err := removeTree(root, filepath.Join(rel, name), o)
+		if err != nil {
+			return false, err
+		}
+		removed = removed && ok
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, os.Remove(path)
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+	return f.Readdirnames(-1)
+}
+
+// underAny checks if the relative path rel is inside one of dirs.
+func underAny(rel string, dirs map[string]bool) bool {
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// indent indents every line of s by two spaces.
+func indent(s string) string {
+	return "  " + strings.Replace(s, "\n", "\n  ", -1)
+}
+
+// copyTree copies the tree src to dst, along with the permissions, and with
+// the modification times if o.ModTime is set. Symbolic links are copied as
+// links if o.Symlinks is set or if they can't be followed, and as the files
+// they point to otherwise.
+func copyTree(src, dst string, o *TreeOptions) error {
+	// The permissions of the directories are set last, in case they are not
+	// writable.
+	type dirPerm struct {
+		path string
+		perm os.FileMode
+	}
+	var dirs []dirPerm
+	err := filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && o.ignored(rel) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if fi.Mode()&os.ModeSymlink != 0 {
+			followed, err := os.Stat(path)
+			if o.Symlinks || err != nil {
+				link, err := os.Readlink(path)
+				if err != nil {
+					return err
+				}
+				return os.Symlink(link, target)
+			}
+			fi = followed
+			if fi.IsDir() {
+				// Don't follow links to directories, which may form cycles.
+				dirs = append(dirs, dirPerm{target, fi.Mode().Perm()})
+				return os.MkdirAll(target, 0755)
+			}
+		}
+		switch {
+		case fi.IsDir():
+			dirs = append(dirs, dirPerm{target, fi.Mode().Perm()})
+			return os.MkdirAll(target, 0755)
+		case fi.Mode().IsRegular():
+			if err := copyFile(path, target, fi.Mode().Perm()); err != nil {
+				return err
+			}
+			if o.ModTime {
+				return os.Chtimes(target, fi.ModTime(), fi.ModTime())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close() // nolint: errcheck
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, perm)
+}
@@ -0,0 +1,164 @@
+package testutil_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grailbio/testutil"
+	"github.com/grailbio/testutil/assert"
+	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	for path, data := range files {
+		path = filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+	}
+}
+
+func gzipped(t *testing.T, s string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestCompareTrees(t *testing.T) {
+	got, golden := t.TempDir(), t.TempDir()
+	writeTree(t, golden, map[string]string{
+		"a.txt":          "a\nb\n",
+		"sub/data.json":  `{"x": 1, "y": [1, 2]}`,
+		"sub/log.txt.gz": gzipped(t, "started\nstopped\n"),
+		"missing.txt":    "",
+		"kind":           "",
+		"logs/run.log":   "old",
+		"sub/old.tmp":    "kept",
+	})
+	writeTree(t, got, map[string]string{
+		"a.txt":          "a\nc\n",
+		"sub/data.json":  `{"y": [1, 2], "x": 1}`,
+		"sub/log.txt.gz": gzipped(t, "started\nfailed\n"),
+		"extra.txt":      "",
+		"kind/file":      "",
+		"logs/run.log":   "new",
+		"sub/new.tmp":    "",
+	})
+	opts := testutil.TreeOptions{
+		Comparators: map[string]testutil.FileComparator{
+			".json": testutil.JSONComparator(),
+			".gz":   testutil.GzipComparator(testutil.TextComparator()),
+		},
+		Ignore: []string{"logs", "sub/*.tmp"},
+	}
+	tb := &goldenTB{TB: t, name: "TestTree"}
+	testutil.CompareTrees(tb, got, golden, opts)
+	expect.That(t, tb.errors, h.ElementsAre(h.AllOf(
+		h.HasSubstr("rerun with UPDATE_GOLDEN=1 to update it:\n"),
+		h.HasSuffix(`
+differs: a.txt
+  --- got
+  +++ `+filepath.Join(golden, "a.txt")+`
+  @@ -1,2 +1,2 @@
+   a
+  -c
+  +b
+extra: extra.txt
+kind: got a directory, want a file
+missing: missing.txt
+differs: sub/log.txt.gz
+  --- got
+  +++ `+filepath.Join(golden, "sub/log.txt.gz")+`
+  @@ -1,2 +1,2 @@
+   started
+  -failed
+  +stopped`))))
+
+	// Update the golden tree, after which the trees match. The ignored golden
+	// files are kept, and the ignored files of got are not copied.
+	setUpdateGolden(t, true)
+	testutil.CompareTrees(t, got, golden, opts)
+	setUpdateGolden(t, false)
+	testutil.CompareTrees(t, got, golden, opts)
+	expect.That(t, filepath.Join(golden, "logs/run.log"), h.FileContains("old"))
+	expect.That(t, filepath.Join(golden, "sub/old.tmp"), h.FileContains("kept"))
+	expect.That(t, filepath.Join(golden, "sub/new.tmp"), h.Not(h.FileExists()))
+	expect.That(t, filepath.Join(golden, "missing.txt"), h.Not(h.FileExists()))
+}
+
+func TestCompareTreesKindMismatches(t *testing.T) {
+	got, golden := t.TempDir(), t.TempDir()
+	writeTree(t, got, map[string]string{"a/x": "", "a-b/y": "", "c": ""})
+	writeTree(t, golden, map[string]string{"a": "", "a-b": "", "c/z": ""})
+	tb := &goldenTB{TB: t, name: "TestTree"}
+	testutil.CompareTrees(tb, got, golden, testutil.TreeOptions{})
+	// The contents of the mismatched directories are not reported.
+	expect.That(t, tb.errors, h.ElementsAre(h.HasSuffix(`:
+a: got a directory, want a file
+a-b: got a directory, want a file
+c: got a file, want a directory`)))
+}
+
+func TestCompareTreesDanglingSymlink(t *testing.T) {
+	got, golden := t.TempDir(), t.TempDir()
+	writeTree(t, golden, map[string]string{"link": "x"})
+	assert.NoError(t, os.Symlink("nowhere", filepath.Join(got, "link")))
+
+	tb := &goldenTB{TB: t, name: "TestTree"}
+	testutil.CompareTrees(tb, got, golden, testutil.TreeOptions{})
+	expect.That(t, tb.errors, h.ElementsAre(h.AllOf(
+		h.HasSubstr("\nlink: cannot follow symlink: stat "+filepath.Join(got, "link")+": "),
+		h.Not(h.HasSubstr("\nlink: got ")))))
+
+	// The update copies the link, which is then compared by its target.
+	setUpdateGolden(t, true)
+	testutil.CompareTrees(t, got, golden, testutil.TreeOptions{})
+	setUpdateGolden(t, false)
+	testutil.CompareTrees(t, got, golden, testutil.TreeOptions{})
+	target, err := os.Readlink(filepath.Join(golden, "link"))
+	expect.NoError(t, err)
+	expect.EQ(t, target, "nowhere")
+}
+
+func TestCompareTreesMetadata(t *testing.T) {
+	got, golden := t.TempDir(), t.TempDir()
+	files := map[string]string{"bin/tool": "#!/bin/sh\n", "data": "x"}
+	writeTree(t, got, files)
+	writeTree(t, golden, files)
+	assert.NoError(t, os.Chmod(filepath.Join(got, "bin/tool"), 0755))
+	assert.NoError(t, os.Symlink("data", filepath.Join(got, "link")))
+	assert.NoError(t, os.Symlink("bin/tool", filepath.Join(golden, "link")))
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(filepath.Join(got, "data"), mtime, mtime))
+
+	// By default, only the contents matter, and links are followed.
+	tb := &goldenTB{TB: t, name: "TestTree"}
+	testutil.CompareTrees(tb, got, golden, testutil.TreeOptions{})
+	expect.That(t, tb.errors, h.ElementsAre(h.HasSuffix("differs: link\n  --- got\n  +++ "+
+		filepath.Join(golden, "link")+"\n  @@ -1,1 +1,2 @@\n  -x\n  +#!/bin/sh\n  +")))
+
+	tb.errors = nil
+	testutil.CompareTrees(tb, got, golden, testutil.TreeOptions{Perm: true, Symlinks: true, ModTime: true})
+	expect.That(t, tb.errors, h.ElementsAre(h.AllOf(
+		h.HasSubstr("\nbin/tool: got permissions -rwxr-xr-x, want -rw-r--r--\n"),
+		h.HasSubstr("\ndata: got modification time 2020-01-02 03:04:05 +0000 UTC, want "),
+		h.HasSuffix("\nlink: got symlink to data, want bin/tool"))))
+
+	// The update copies the metadata that is compared.
+	opts := testutil.TreeOptions{Perm: true, Symlinks: true, ModTime: true}
+	setUpdateGolden(t, true)
+	testutil.CompareTrees(t, got, golden, opts)
+	setUpdateGolden(t, false)
+	testutil.CompareTrees(t, got, golden, opts)
+	target, err := os.Readlink(filepath.Join(golden, "link"))
+	expect.NoError(t, err)
+	expect.EQ(t, target, "data")
+}
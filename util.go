@@ -20,6 +20,7 @@ import (
 
 	"github.com/grailbio/testutil/assert"
 	"github.com/grailbio/testutil/expect"
+	"github.com/grailbio/testutil/h"
 )
 
 // MockTB is a mock implementation of gosh.TB. FailNow and Fatalf will
@@ -141,22 +142,20 @@ func GetTmpPath() string {
 	return GetTmpDir() + fileName
 }
 
-// WriteTmp writes the supplied contents to a temporary file and returns the
-// name of that file.
-func writeTmp(t testing.TB, contents string) string {
-	f, err := ioutil.TempFile("", "WriteTmp-")
-	assert.NoError(t, err)
-	_, err = f.Write([]byte(contents))
-	assert.NoError(t, err)
-	return f.Name()
-}
-
 // CompareFile compares the supplied contents against the contents of the
-// specified file and if they differ calls t.Errorf and displays a diff -u of
-// them. If specified the strip function can be used to cleanup the contents to
-// be compared to remove things such as dates or other spurious information
-// that's not relevant to the comparison.
+// specified file and if they differ calls t.Errorf and displays a unified diff
+// of them. If specified the strip function can be used to cleanup the contents
+// to be compared to remove things such as dates or other spurious information
+// that's not relevant to the comparison; Normalize chains several such
+// functions. When the golden files are being updated (see UpdatingGolden), the
+// contents are written to the golden file instead.
 func CompareFile(t testing.TB, contents string, golden string, strip func(string) string) {
+	t.Helper()
+	if UpdatingGolden() {
+		assert.NoError(t, ioutil.WriteFile(golden, []byte(contents), 0644))
+		t.Logf("updated golden file %s", golden)
+		return
+	}
 	data, err := ioutil.ReadFile(golden)
 	assert.NoError(t, err)
 	got, want := contents, string(data)
@@ -164,13 +163,8 @@ func CompareFile(t testing.TB, contents string, golden string, strip func(string
 		got, want = strip(got), strip(want)
 	}
 	if got != want {
-		gf := writeTmp(t, got)
-		defer os.Remove(gf) // nolint: errcheck
-		cmd := exec.Command("diff", "-u", gf, golden)
-		diff, _ := cmd.CombinedOutput()
 		t.Logf("got %v", got)
-		t.Logf("diff %v %v", gf, golden)
-		expect.True(t, false, "Golden: %v, diff: %v", golden, string(diff))
+		expect.True(t, false, "Golden: %v, diff: %v", golden, h.UnifiedDiff("got", golden, got, want))
 	}
 }
 